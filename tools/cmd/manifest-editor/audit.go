@@ -0,0 +1,162 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gemini/dnshield/internal/meditor/audit"
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/session"
+)
+
+var auditLogger *audit.Logger
+
+// initAuditLogger opens the append-only audit log under the same config
+// directory as sessions.db and config.json.
+func initAuditLogger() error {
+	usr, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	configDir := filepath.Join(usr.HomeDir, ".config", "dnshield-manifest-editor")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		return err
+	}
+
+	auditLogger, err = audit.Open(filepath.Join(configDir, "audit.log"))
+	return err
+}
+
+// logAudit appends an audit entry for a mutating action. Failures are
+// logged but never block the request: a write that succeeded shouldn't
+// fail the caller just because the audit trail couldn't be recorded.
+func logAudit(r *http.Request, sess *session.Session, action, path, diffSummary string, prNumber int, prURL, fingerprint string) {
+	if auditLogger == nil {
+		return
+	}
+	login := ""
+	if sess != nil {
+		login = sess.Login
+	}
+	entry := audit.Entry{
+		Timestamp:   time.Now(),
+		Login:       login,
+		RemoteIP:    remoteIP(r),
+		Action:      action,
+		Path:        path,
+		DiffSummary: diffSummary,
+		PRNumber:    prNumber,
+		PRURL:       prURL,
+		Fingerprint: fingerprint,
+	}
+	if err := auditLogger.Append(entry); err != nil {
+		log.Printf("Warning: failed to write audit log entry: %v", err)
+	}
+}
+
+// remoteIP strips the port from r.RemoteAddr, falling back to the raw
+// value if it isn't in host:port form (e.g. behind some proxies).
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// handleAudit serves GET /api/audit?since=<RFC3339>&user=<login>,
+// returning matching entries oldest-first.
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		http.Error(w, "Failed to resolve audit log path: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	path := filepath.Join(usr.HomeDir, ".config", "dnshield-manifest-editor", "audit.log")
+
+	entries, err := audit.ReadAll(path)
+	if err != nil {
+		http.Error(w, "Failed to read audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	userFilter := r.URL.Query().Get("user")
+
+	filtered := make([]audit.Entry, 0, len(entries))
+	for _, e := range entries {
+		if !since.IsZero() && e.Timestamp.Before(since) {
+			continue
+		}
+		if userFilter != "" && e.Login != userFilter {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	page, pageSize := 1, 100
+	if v := r.URL.Query().Get("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{
+		"entries": filtered[start:end],
+		"total":   len(filtered),
+		"page":    page,
+	})
+}
+
+func scopeForAuditRequest(r *http.Request) (authz.Scope, error) {
+	return authz.ScopeAuditRead, nil
+}
+
+// runAuditVerify implements the `manifest-editor verify` subcommand: it
+// walks the audit log's hash chain and reports whether it's intact.
+func runAuditVerify() int {
+	usr, err := user.Current()
+	if err != nil {
+		log.Printf("Failed to resolve audit log path: %v", err)
+		return 1
+	}
+	path := filepath.Join(usr.HomeDir, ".config", "dnshield-manifest-editor", "audit.log")
+
+	if err := audit.Verify(path); err != nil {
+		log.Printf("Audit log verification FAILED: %v", err)
+		return 1
+	}
+	log.Printf("Audit log verification OK: %s", path)
+	return 0
+}
@@ -0,0 +1,195 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/feeds"
+	"github.com/gemini/dnshield/internal/meditor/forge"
+	"github.com/gemini/dnshield/internal/meditor/policy"
+)
+
+// feedsScheduler runs the feeds.yaml-declared syncs in the background,
+// nil if the configured repository has no feeds.yaml.
+var feedsScheduler *feeds.Scheduler
+
+// startFeedsScheduler (re)points feedsScheduler at baseDir's feeds.yaml,
+// closing any previous scheduler first (e.g. when the configured
+// repository changes). A missing feeds.yaml means no feeds are
+// configured for this repository - not an error.
+func startFeedsScheduler(baseDir string) {
+	if feedsScheduler != nil {
+		_ = feedsScheduler.Close()
+		feedsScheduler = nil
+	}
+	if baseDir == "" {
+		return
+	}
+
+	path := filepath.Join(baseDir, "feeds.yaml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	cfg, err := feeds.Load(path)
+	if err != nil {
+		log.Printf("Warning: failed to load feeds.yaml: %v", err)
+		return
+	}
+
+	sched, err := feeds.Start(cfg.Feeds, syncFeed)
+	if err != nil {
+		log.Printf("Warning: failed to start feeds scheduler: %v", err)
+		return
+	}
+	feedsScheduler = sched
+}
+
+// syncFeed fetches one feed, diffs it against its manifest's current
+// domain list, and - if anything changed - commits the update to a new
+// branch and opens a PR through the configured forge. It returns the
+// opened PR's URL, or "" if the feed had nothing new to commit, or if
+// an identical PR is already open.
+func syncFeed(ctx context.Context, feed feeds.Feed) (string, error) {
+	fullPath := filepath.Join(config.ManifestsDir, feed.Manifest)
+	manifest, err := loadManifest(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	ruleType := feed.RuleType
+	if ruleType == "" {
+		ruleType = ruleTypeBlock
+	}
+	managedRules, ruleType, existingDomains, err := prepareManagedRules(manifest, feed.Manifest, ruleType)
+	if err != nil {
+		return "", err
+	}
+
+	fetched, err := feeds.FetchDomains(ctx, feed.URL)
+	if err != nil {
+		return "", err
+	}
+
+	added, removed := feeds.Diff(existingDomains, fetched, feed.NeverRemove)
+	if len(added) == 0 && len(removed) == 0 {
+		return "", nil
+	}
+
+	updated, _ := filterDomains(existingDomains, removed)
+	updated, _ = mergeDomains(updated, added)
+	managedRules[ruleType] = updated
+	updateRuleMetadata(manifest, ruleType, updated)
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	content = append(content, '\n')
+
+	var rule *policy.Rule
+	if pol, err := loadDnshieldPolicy(); err != nil {
+		log.Printf("[feeds] WARNING: failed to load .dnshield.yml: %v", err)
+	} else {
+		rule = pol.RuleForPath(feed.Manifest)
+	}
+
+	templateData := policy.TemplateData{
+		ManifestName:   feed.Manifest,
+		RuleType:       ruleType,
+		AddedDomains:   added,
+		RemovedDomains: removed,
+		TotalDomains:   len(updated),
+		UserHandle:     "feeds-scheduler",
+		Timestamp:      time.Now(),
+		Diff:           domainsDiffText(added, removed),
+	}
+	prTitle, err := policy.RenderTitle(rule, templateData, "")
+	if err != nil {
+		return "", err
+	}
+
+	provider, defaultOwner, defaultRepo, err := newForgeProvider(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	open, err := provider.ListOpenPRs(ctx, defaultOwner, defaultRepo)
+	if err != nil {
+		return "", err
+	}
+	for _, pr := range open {
+		if pr.Title == prTitle {
+			log.Printf("[feeds] %s: identical PR #%d already open, skipping", feed.Name, pr.Number)
+			return "", nil
+		}
+	}
+
+	baseBranch := "main"
+	if rule != nil && rule.BaseBranch != "" {
+		baseBranch = rule.BaseBranch
+	}
+	baseSHA, err := provider.GetBaseRef(ctx, defaultOwner, defaultRepo, baseBranch)
+	if err != nil {
+		return "", err
+	}
+
+	branch := generateFeatureBranchName("feeds/"+feed.Name, "")
+	if err := provider.CreateBranch(ctx, defaultOwner, defaultRepo, branch, baseSHA); err != nil {
+		return "", err
+	}
+
+	files := []forge.FileSpec{{Path: feed.Manifest, ContentBase64: base64.StdEncoding.EncodeToString(content)}}
+	if _, err := provider.CommitFilesAtomic(ctx, defaultOwner, defaultRepo, branch, baseSHA, files, prTitle); err != nil {
+		return "", err
+	}
+
+	prBody, err := policy.RenderBody(rule, templateData, "")
+	if err != nil {
+		return "", err
+	}
+	pr, err := provider.OpenPR(ctx, defaultOwner, defaultRepo, prTitle, prBody, branch, baseBranch)
+	if err != nil {
+		return "", err
+	}
+
+	if rule != nil && (len(rule.Reviewers) > 0 || len(rule.Labels) > 0) {
+		if err := provider.ApplyReviewAndLabels(ctx, defaultOwner, defaultRepo, pr.Number, rule.Reviewers, rule.Labels); err != nil {
+			log.Printf("[feeds] WARNING: failed to apply reviewers/labels from .dnshield.yml: %v", err)
+		}
+	}
+
+	log.Printf("[feeds] %s: opened PR #%d (%s)", feed.Name, pr.Number, pr.URL)
+	return pr.URL, nil
+}
+
+// handleFeeds serves GET /api/feeds, reporting each feeds.yaml-declared
+// feed's last sync result and next scheduled run.
+func handleFeeds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if feedsScheduler == nil {
+		writeJSON(w, map[string]interface{}{"feeds": []feeds.Status{}})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"feeds": feedsScheduler.Statuses()})
+}
+
+func scopeForFeedsRequest(r *http.Request) (authz.Scope, error) {
+	return authz.ScopeManifestRead, nil
+}
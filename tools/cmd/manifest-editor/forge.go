@@ -0,0 +1,203 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/gemini/dnshield/internal/meditor/forge"
+)
+
+// ForgeConfig holds the GitLab/Gitea/Bitbucket/Azure DevOps connection
+// details manifest-editor reads from the environment, for deployments
+// whose forge_type points PR creation at one of them instead of GitHub,
+// plus the GitHub provider's clone-mode and commit-signing settings.
+type ForgeConfig struct {
+	GitLabBaseURL      string
+	GitLabToken        string
+	GitLabDefaultOwner string
+	GitLabDefaultRepo  string
+
+	GiteaBaseURL      string
+	GiteaToken        string
+	GiteaDefaultOwner string
+	GiteaDefaultRepo  string
+
+	BitbucketBaseURL      string
+	BitbucketToken        string
+	BitbucketDefaultOwner string
+	BitbucketDefaultRepo  string
+
+	AzureDevOpsToken        string
+	AzureDevOpsDefaultOwner string // "organization/project"
+	AzureDevOpsDefaultRepo  string
+
+	GitHubCloneModeFileThreshold int
+	GitHubCloneBaseURL           string
+	GitHubSigningKeyPath         string
+}
+
+var forgeConfig ForgeConfig
+
+// initForgeConfig reads the GitLab, Gitea, Bitbucket, and Azure DevOps
+// connection details (and the GitHub clone-mode/signing settings) from
+// the environment. All are optional - a deployment only needs whichever
+// forge its forge_type setting actually selects.
+func initForgeConfig() {
+	forgeConfig = ForgeConfig{
+		GitLabBaseURL:      envOr("GL_BASE_URL", "https://gitlab.com"),
+		GitLabToken:        os.Getenv("GL_TOKEN"),
+		GitLabDefaultOwner: os.Getenv("GL_DEFAULT_OWNER"),
+		GitLabDefaultRepo:  os.Getenv("GL_DEFAULT_REPO"),
+
+		GiteaBaseURL:      os.Getenv("GITEA_BASE_URL"),
+		GiteaToken:        os.Getenv("GITEA_TOKEN"),
+		GiteaDefaultOwner: os.Getenv("GITEA_DEFAULT_OWNER"),
+		GiteaDefaultRepo:  os.Getenv("GITEA_DEFAULT_REPO"),
+
+		BitbucketBaseURL:      os.Getenv("BITBUCKET_BASE_URL"),
+		BitbucketToken:        os.Getenv("BITBUCKET_TOKEN"),
+		BitbucketDefaultOwner: os.Getenv("BITBUCKET_DEFAULT_OWNER"),
+		BitbucketDefaultRepo:  os.Getenv("BITBUCKET_DEFAULT_REPO"),
+
+		AzureDevOpsToken:        os.Getenv("AZURE_DEVOPS_TOKEN"),
+		AzureDevOpsDefaultOwner: os.Getenv("AZURE_DEVOPS_DEFAULT_OWNER"),
+		AzureDevOpsDefaultRepo:  os.Getenv("AZURE_DEVOPS_DEFAULT_REPO"),
+
+		GitHubCloneModeFileThreshold: envOrInt("GITHUB_CLONE_MODE_FILE_THRESHOLD", 0),
+		GitHubCloneBaseURL:           os.Getenv("GITHUB_CLONE_BASE_URL"),
+		GitHubSigningKeyPath:         os.Getenv("GITHUB_COMMIT_SIGNING_KEY_PATH"),
+	}
+}
+
+// envOrInt parses key as an integer, falling back to fallback if the
+// variable is unset or not a valid integer.
+func envOrInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// loadGitHubSigningKey reads and parses the armored PGP private key at
+// path, for deployments that want manifest-editor's clone-mode commits
+// GPG-signed. A nil result with no error means no key is configured.
+func loadGitHubSigningKey(path string) (*openpgp.Entity, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GitHub commit signing key: %w", err)
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub commit signing key: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("GitHub commit signing key file contains no keys")
+	}
+	return entities[0], nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// forgeType resolves which forge PR creation targets: the repository
+// configuration's forge_type if the operator set one, defaulting to
+// GitHub so every deployment predating this setting keeps working
+// unchanged.
+func forgeType() string {
+	if appConfig.ForgeType != "" {
+		return appConfig.ForgeType
+	}
+	return "github"
+}
+
+// newForgeProvider builds the Provider for the configured forge, along
+// with the owner/repo to fall back to when a PR request doesn't specify
+// one. userToken, if set, authenticates as the signed-in user instead of
+// the forge's service token/app installation, the same on-behalf-of
+// pattern createGitHubPR already used for GitHub alone.
+func newForgeProvider(ctx context.Context, userToken string) (forge.Provider, string, string, error) {
+	switch forgeType() {
+	case "gitlab":
+		if forgeConfig.GitLabToken == "" && userToken == "" {
+			return nil, "", "", fmt.Errorf("gitlab forge selected but GL_TOKEN is not set")
+		}
+		token := forgeConfig.GitLabToken
+		if userToken != "" {
+			token = userToken
+		}
+		return forge.NewGitLabProvider(forgeConfig.GitLabBaseURL, token), forgeConfig.GitLabDefaultOwner, forgeConfig.GitLabDefaultRepo, nil
+
+	case "gitea":
+		if forgeConfig.GiteaToken == "" && userToken == "" {
+			return nil, "", "", fmt.Errorf("gitea forge selected but GITEA_TOKEN is not set")
+		}
+		token := forgeConfig.GiteaToken
+		if userToken != "" {
+			token = userToken
+		}
+		return forge.NewGiteaProvider(forgeConfig.GiteaBaseURL, token), forgeConfig.GiteaDefaultOwner, forgeConfig.GiteaDefaultRepo, nil
+
+	case "bitbucket":
+		if forgeConfig.BitbucketToken == "" && userToken == "" {
+			return nil, "", "", fmt.Errorf("bitbucket forge selected but BITBUCKET_TOKEN is not set")
+		}
+		token := forgeConfig.BitbucketToken
+		if userToken != "" {
+			token = userToken
+		}
+		provider := forge.NewBitbucketProvider(token)
+		provider.BaseURL = forgeConfig.BitbucketBaseURL
+		return provider, forgeConfig.BitbucketDefaultOwner, forgeConfig.BitbucketDefaultRepo, nil
+
+	case "azuredevops":
+		if forgeConfig.AzureDevOpsToken == "" && userToken == "" {
+			return nil, "", "", fmt.Errorf("azuredevops forge selected but AZURE_DEVOPS_TOKEN is not set")
+		}
+		token := forgeConfig.AzureDevOpsToken
+		if userToken != "" {
+			token = userToken
+		}
+		return forge.NewAzureDevOpsProvider(token), forgeConfig.AzureDevOpsDefaultOwner, forgeConfig.AzureDevOpsDefaultRepo, nil
+
+	default:
+		if ghAppConfig == nil || tokenService == nil {
+			return nil, "", "", fmt.Errorf("GitHub App not configured")
+		}
+		// GitHubProvider calls factory.NewClient(ctx) fresh per method
+		// call, reading whatever withUserToken/withCommitWithApp value
+		// the caller already set on the ctx passed to that call - the
+		// same per-call token selection createGitHubPR always used.
+		factory := &GitHubClientFactory{tokenService: tokenService}
+		signingKey, err := loadGitHubSigningKey(forgeConfig.GitHubSigningKeyPath)
+		if err != nil {
+			return nil, "", "", err
+		}
+		provider := &forge.GitHubProvider{
+			Factory:                factory,
+			CloneModeFileThreshold: forgeConfig.GitHubCloneModeFileThreshold,
+			CloneBaseURL:           forgeConfig.GitHubCloneBaseURL,
+			SigningKey:             signingKey,
+		}
+		return provider, ghAppConfig.DefaultOwner, ghAppConfig.DefaultRepo, nil
+	}
+}
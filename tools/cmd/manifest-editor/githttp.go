@@ -0,0 +1,190 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/session"
+)
+
+// deviceTokenTTL bounds how long a minted device token is usable for,
+// so a lost or retired agent's credential eventually stops working on
+// its own rather than needing to be revoked by hand.
+const deviceTokenTTL = 90 * 24 * time.Hour
+
+// handleDeviceToken mints a long-lived, signed session token an agent
+// can present over HTTP Basic auth to the git smart-HTTP endpoint,
+// without ever handing the agent the user's own GitHub OAuth token. The
+// minted session carries the same login/token the caller is signed in
+// with, so it's subject to exactly the scopes roles.yaml grants them.
+func handleDeviceToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess := currentSession(r)
+	if sess == nil || sess.AccessToken == "" {
+		http.Error(w, "Authentication required. Please sign in via GitHub.", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID, err := sessionStore.New()
+	if err != nil {
+		http.Error(w, "Failed to create device token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	device := &session.Session{
+		ID:          deviceID,
+		AccessToken: sess.AccessToken,
+		Login:       sess.Login,
+		Name:        sess.Name,
+		ExpiresAt:   time.Now().Add(deviceTokenTTL),
+	}
+	if err := sessionStore.Save(device); err != nil {
+		http.Error(w, "Failed to save device token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]string{"token": sessionStore.Sign(deviceID)})
+}
+
+// handleGitSmartHTTP serves the manifests repo over the git Smart HTTP
+// protocol (https://git-scm.com/docs/http-protocol) under
+// /git/manifests.git/, so agents can `git clone`/`git pull` (and, given
+// write scope, `git push`) directly against the editor host instead of
+// needing out-of-band access to the upstream GitHub repo.
+func handleGitSmartHTTP(w http.ResponseWriter, r *http.Request) {
+	sess, ok := gitBasicAuth(w, r)
+	if !ok {
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/git/manifests.git/")
+	switch {
+	case r.Method == http.MethodGet && rest == "info/refs":
+		handleGitInfoRefs(w, r, sess)
+	case r.Method == http.MethodPost && rest == "git-upload-pack":
+		handleGitService(w, r, "upload-pack", sess)
+	case r.Method == http.MethodPost && rest == "git-receive-pack":
+		handleGitService(w, r, "receive-pack", sess)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// gitBasicAuth validates the request's HTTP Basic password as a signed
+// session token (either a browser's own cookie value or a device token
+// from handleDeviceToken) and returns the session it resolves to. The
+// username is ignored, matching how GitHub's own git-over-HTTPS treats
+// a personal access token as the password.
+func gitBasicAuth(w http.ResponseWriter, r *http.Request) (*session.Session, bool) {
+	_, password, ok := r.BasicAuth()
+	if ok {
+		if id, verified := sessionStore.Verify(password); verified {
+			if sess, found, err := sessionStore.Get(id); err == nil && found {
+				return sess, true
+			}
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="dnshield-manifests"`)
+	http.Error(w, "Authentication required", http.StatusUnauthorized)
+	return nil, false
+}
+
+// requireGitScope resolves sess's scopes and checks them against what
+// service needs: read access to pull, and the broad write scope to push
+// since a single push can touch any file in the tree.
+func requireGitScope(ctx context.Context, sess *session.Session, service string) error {
+	scopes, err := userScopes(ctx, sess)
+	if err != nil {
+		return fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+	required := authz.ScopeManifestRead
+	if service == "receive-pack" {
+		required = authz.ScopeManifestWriteAny
+	}
+	if !authz.Allows(scopes, required) {
+		return fmt.Errorf("missing scope %q", required)
+	}
+	return nil
+}
+
+// handleGitInfoRefs answers the ref-advertisement request every git
+// client makes before upload-pack or receive-pack, by running
+// `git <service> --stateless-rpc --advertise-refs` and wrapping its
+// output in the pkt-line service announcement the protocol expects.
+func handleGitInfoRefs(w http.ResponseWriter, r *http.Request, sess *session.Session) {
+	service := r.URL.Query().Get("service")
+	if service != "git-upload-pack" && service != "git-receive-pack" {
+		http.Error(w, "Unsupported git service", http.StatusBadRequest)
+		return
+	}
+	gitCmd := strings.TrimPrefix(service, "git-")
+	if err := requireGitScope(r.Context(), sess, gitCmd); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	cmd := exec.CommandContext(r.Context(), "git", gitCmd, "--stateless-rpc", "--advertise-refs", config.BaseDir) //nolint:gosec // gitCmd is checked against a fixed allowlist above
+	out, err := cmd.Output()
+	if err != nil {
+		log.Printf("[git] %s --advertise-refs failed: %v", gitCmd, err)
+		http.Error(w, "git "+gitCmd+" failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", service))
+	w.Header().Set("Cache-Control", "no-cache")
+	writePktLine(w, "# service="+service+"\n")
+	w.Write([]byte("0000"))
+	w.Write(out)
+}
+
+// handleGitService streams a POST git-upload-pack/git-receive-pack
+// request body (gzip-decoded if the client compressed it) into the
+// matching `git <service> --stateless-rpc` subprocess, and streams its
+// stdout straight back as the response.
+func handleGitService(w http.ResponseWriter, r *http.Request, service string, sess *session.Session) {
+	if err := requireGitScope(r.Context(), sess, service); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	body := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	cmd := exec.CommandContext(r.Context(), "git", service, "--stateless-rpc", config.BaseDir) //nolint:gosec // service is one of a fixed allowlist
+	cmd.Stdin = body
+
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-git-%s-result", service))
+	w.Header().Set("Cache-Control", "no-cache")
+	cmd.Stdout = w
+	if err := cmd.Run(); err != nil {
+		log.Printf("[git] %s failed: %v", service, err)
+	}
+}
+
+// writePktLine writes s as a single git pkt-line: a 4-byte hex length
+// prefix (including itself) followed by the payload.
+func writePktLine(w http.ResponseWriter, s string) {
+	fmt.Fprintf(w, "%04x%s", len(s)+4, s)
+}
@@ -0,0 +1,203 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// visitState is a node's DFS status while building an InheritanceGraph:
+// white (unvisited), gray (on the current path - revisiting one means a
+// real cycle), black (fully resolved, safe to revisit without reporting
+// anything).
+type visitState int
+
+const (
+	white visitState = iota
+	gray
+	black
+)
+
+// InheritanceGraph is the resolved included_manifests/catalogs graph for
+// every manifest under config.ManifestsDir: parent->child edges, any
+// cycles found while walking it, and any reference to a manifest file
+// that doesn't exist on disk.
+type InheritanceGraph struct {
+	Edges   map[string][]string `json:"edges"`
+	Cycles  [][]string          `json:"cycles"`
+	Missing []string            `json:"missing"`
+	Orphans []string            `json:"orphans"`
+}
+
+var (
+	inheritanceGraphMu     sync.Mutex
+	cachedInheritanceGraph *InheritanceGraph
+)
+
+// invalidateInheritanceGraph drops the cached graph, so the next lint
+// request rebuilds it from the current on-disk files rather than serving
+// a stale one. Called alongside invalidateCaches.
+func invalidateInheritanceGraph() {
+	inheritanceGraphMu.Lock()
+	cachedInheritanceGraph = nil
+	inheritanceGraphMu.Unlock()
+}
+
+// getInheritanceGraph returns the cached InheritanceGraph, building it
+// first if the cache was empty or invalidated.
+func getInheritanceGraph() *InheritanceGraph {
+	inheritanceGraphMu.Lock()
+	defer inheritanceGraphMu.Unlock()
+	if cachedInheritanceGraph == nil {
+		cachedInheritanceGraph = buildInheritanceGraph()
+	}
+	return cachedInheritanceGraph
+}
+
+// buildInheritanceGraph walks every entity manifest (top-level and
+// machines/*.json) as a root, following included_manifests/catalogs edges
+// with tri-state visit marks to tell a genuine cycle from a diamond
+// dependency reached twice. Include files that exist on disk but are
+// never reached as a child of any root are reported as orphans.
+func buildInheritanceGraph() *InheritanceGraph {
+	graph := &InheritanceGraph{Edges: make(map[string][]string)}
+	if config.ManifestsDir == "" {
+		return graph
+	}
+
+	state := make(map[string]visitState)
+	missingSeen := make(map[string]bool)
+	referenced := make(map[string]bool)
+	// rootPaths maps a root's graph name to its real manifest-relative
+	// path, since determineManifestPath/cleanManifestName only know how
+	// to resolve includes/* children by bare name - they have no rule
+	// for machines/*.json or top-level default.json/site_default.json,
+	// so roots must keep their own real path rather than being put
+	// through that include-only convention.
+	rootPaths := make(map[string]string)
+
+	var visit func(name string, path []string)
+	visit = func(name string, path []string) {
+		switch state[name] {
+		case gray:
+			for i, n := range path {
+				if n == name {
+					cycle := append(append([]string{}, path[i:]...), name)
+					graph.Cycles = append(graph.Cycles, cycle)
+					return
+				}
+			}
+			return
+		case black:
+			return
+		}
+
+		state[name] = gray
+		path = append(path, name)
+		defer func() { state[name] = black }()
+
+		relPath, isRoot := rootPaths[name]
+		if !isRoot {
+			relPath = determineManifestPath(name)
+		}
+		fullPath := filepath.Join(config.ManifestsDir, relPath)
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			if !missingSeen[name] {
+				missingSeen[name] = true
+				graph.Missing = append(graph.Missing, name)
+			}
+			return
+		}
+
+		var manifest map[string]interface{}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return
+		}
+
+		var children []string
+		if included, ok := manifest["included_manifests"].([]interface{}); ok {
+			for _, m := range included {
+				if str, ok := m.(string); ok {
+					children = append(children, cleanManifestName(str))
+				}
+			}
+		}
+		if catalogs, ok := manifest["catalogs"].([]interface{}); ok {
+			for _, catalog := range catalogs {
+				if str, ok := catalog.(string); ok {
+					children = append(children, cleanManifestName(str))
+				} else if cat, ok := catalog.(map[string]interface{}); ok {
+					if cname, ok := cat["name"].(string); ok {
+						children = append(children, cleanManifestName(cname))
+					}
+				}
+			}
+		}
+
+		if len(children) > 0 {
+			graph.Edges[name] = children
+		}
+		for _, child := range children {
+			referenced[child] = true
+			visit(child, path)
+		}
+	}
+
+	var roots, includeNames []string
+	_ = filepath.WalkDir(config.ManifestsDir, func(walkPath string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(walkPath) != ".json" {
+			return nil
+		}
+		rel, relErr := filepath.Rel(config.ManifestsDir, walkPath)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, "includes/") {
+			includeNames = append(includeNames, cleanManifestName(rel))
+			return nil
+		}
+		// Roots (machines/*.json, default.json, site_default.json, ...)
+		// keep their real relative path as their graph name instead of
+		// being collapsed to a bare basename - see rootPaths above.
+		name := strings.TrimSuffix(rel, ".json")
+		rootPaths[name] = rel
+		roots = append(roots, name)
+		return nil
+	})
+
+	for _, root := range roots {
+		visit(root, nil)
+	}
+	for _, include := range includeNames {
+		visit(include, nil)
+	}
+
+	for _, include := range includeNames {
+		if !referenced[include] {
+			graph.Orphans = append(graph.Orphans, include)
+		}
+	}
+
+	return graph
+}
+
+// handleManifestLint serves the cached InheritanceGraph so the frontend
+// can flag circular or dangling manifest references before they trip up
+// an actual rule sync.
+func handleManifestLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, getInheritanceGraph())
+}
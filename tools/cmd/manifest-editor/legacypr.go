@@ -0,0 +1,287 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/session"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gogithttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v66/github"
+)
+
+// handlePullRequest serves the legacy single-file PR endpoint: commit one
+// already-saved manifest change and open a PR for it, as an alternative
+// to the multi-file GitHub-App flow handleGitHubPR exposes.
+func handlePullRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req LegacyPRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sess := currentSession(r)
+	if sess == nil || sess.AccessToken == "" {
+		http.Error(w, "Authentication required. Please sign in via GitHub.", http.StatusUnauthorized)
+		return
+	}
+
+	scopes, err := userScopes(r.Context(), sess)
+	if err != nil {
+		http.Error(w, "Failed to resolve permissions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	required := authz.ScopeForManifestPath(req.File)
+	if !authz.Allows(scopes, required) {
+		http.Error(w, fmt.Sprintf("Forbidden: missing scope %q for %s", required, req.File), http.StatusForbidden)
+		return
+	}
+	if !authz.Allows(scopes, authz.ScopePRCreate) {
+		http.Error(w, fmt.Sprintf("Forbidden: missing scope %q", authz.ScopePRCreate), http.StatusForbidden)
+		return
+	}
+
+	result := createPullRequest(r.Context(), sess, req.Branch, req.Title, req.Description, req.File)
+	if result.Success {
+		logAudit(r, sess, "pr.create", req.File, "", 0, result.Message, "")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, result)
+}
+
+// createPullRequest commits filePath's already-saved changes to a new
+// branch and opens a PR for it, using go-git against the local manifests
+// checkout for the branch/stage/commit/push steps and the GitHub API
+// (like createGitHubPR) to open the PR itself. This replaces shelling out
+// to `git` and the GitHub CLI, so the endpoint works without either
+// installed - including headless and on Windows.
+func createPullRequest(ctx context.Context, sess *session.Session, branch, title, description, filePath string) LegacyPRResponse {
+	repo, err := git.PlainOpen(config.BaseDir)
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to open repository: " + err.Error()}
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to open worktree: " + err.Error()}
+	}
+
+	manifestPath := filepath.Join("manifests", filePath)
+	status, err := wt.Status()
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to check repository status: " + err.Error()}
+	}
+	fileStatus := status.File(manifestPath)
+	if fileStatus.Worktree == git.Unmodified && fileStatus.Staging == git.Unmodified {
+		return LegacyPRResponse{
+			Success: false,
+			Error:   "No changes detected in the file. Please make sure you've saved changes first.",
+		}
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to resolve HEAD: " + err.Error()}
+	}
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if _, err := repo.Reference(branchRef, false); err == nil {
+		return LegacyPRResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Branch %q already exists. Use a different branch name.", branch),
+		}
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branchRef, head.Hash())); err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to create branch: " + err.Error()}
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: branchRef}); err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to switch to branch: " + err.Error()}
+	}
+
+	if _, err := wt.Add(manifestPath); err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to stage changes: " + err.Error()}
+	}
+
+	authorName := sess.Name
+	if authorName == "" {
+		authorName = sess.Login
+	}
+	_, err = wt.Commit(title, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  authorName,
+			Email: sess.Login + "@users.noreply.github.com",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to commit changes: " + err.Error()}
+	}
+
+	token, err := resolveGitToken(sess)
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to resolve GitHub credentials: " + err.Error()}
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: "Failed to resolve git remote: " + err.Error()}
+	}
+	pushErr := repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(branchRef + ":" + branchRef)},
+		Auth:       &gogithttp.BasicAuth{Username: sess.Login, Password: token},
+	})
+	if pushErr != nil {
+		if strings.Contains(pushErr.Error(), "already exists") || strings.Contains(pushErr.Error(), "already up-to-date") {
+			return LegacyPRResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Branch %q or PR already exists. Use a different branch name.", branch),
+			}
+		}
+		return LegacyPRResponse{Success: false, Error: "Failed to push branch: " + pushErr.Error()}
+	}
+
+	owner, repoName, err := remoteOwnerRepo(remote)
+	if err != nil {
+		return LegacyPRResponse{Success: false, Error: err.Error()}
+	}
+
+	gh := github.NewClient(nil).WithAuthToken(token)
+	if ghAppConfig != nil && ghAppConfig.APIBase != defaultGitHubAPIBase && ghAppConfig.APIBase != "" {
+		gh, _ = gh.WithEnterpriseURLs(ghAppConfig.APIBase, ghAppConfig.APIBase)
+	}
+
+	pr, _, err := gh.PullRequests.Create(ctx, owner, repoName, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(branch),
+		Base:  github.String(head.Name().Short()),
+		Body:  github.String(description),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "auth") {
+			return LegacyPRResponse{
+				Success: false,
+				Error:   "GitHub authentication failed. Please sign in again.",
+			}
+		}
+		return LegacyPRResponse{Success: false, Error: "Failed to create pull request: " + err.Error()}
+	}
+
+	return LegacyPRResponse{
+		Success: true,
+		Branch:  branch,
+		Message: fmt.Sprintf("Pull request created successfully! View at: %s", pr.GetHTMLURL()),
+	}
+}
+
+// resolveGitToken returns the credential used both to push over HTTPS and
+// to call the GitHub API on the user's behalf: the signed-in session's
+// OAuth token if there is one, falling back to a token read from
+// ~/.netrc for headless setups (CI, a server with no browser to sign in
+// from) that have none.
+func resolveGitToken(sess *session.Session) (string, error) {
+	if sess != nil && sess.AccessToken != "" {
+		return sess.AccessToken, nil
+	}
+	token, err := netrcPassword("github.com")
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("no active GitHub session and no github.com entry in ~/.netrc")
+	}
+	return token, nil
+}
+
+// netrcPassword looks up the password entry for machine in the current
+// user's ~/.netrc, the same minimal "machine/login/password" format curl
+// and git's own credential helpers read. Returns "" (no error) if the
+// file or the machine entry doesn't exist.
+func netrcPassword(machine string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".netrc"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(data))
+	var inMachine bool
+	var password string
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			i++
+			inMachine = i < len(fields) && fields[i] == machine
+		case "password":
+			i++
+			if inMachine && i < len(fields) {
+				password = fields[i]
+			}
+		}
+	}
+	return password, nil
+}
+
+// remoteOwnerRepo extracts the "owner/repo" GitHub coordinates remote
+// points at. ghAppConfig's configured default takes precedence when set,
+// since that's how operators point the editor at a GitHub Enterprise
+// Server host the remote URL's hostname alone wouldn't identify.
+func remoteOwnerRepo(remote *git.Remote) (owner, repoName string, err error) {
+	if ghAppConfig != nil && ghAppConfig.DefaultOwner != "" && ghAppConfig.DefaultRepo != "" {
+		return ghAppConfig.DefaultOwner, ghAppConfig.DefaultRepo, nil
+	}
+
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", "", fmt.Errorf("remote %q has no URL", remote.Config().Name)
+	}
+	path := strings.TrimSuffix(urls[0], ".git")
+
+	switch {
+	case strings.Contains(path, "@") && !strings.Contains(path, "://"):
+		// scp-like syntax: git@host:owner/repo
+		parts := strings.SplitN(path, ":", 2)
+		if len(parts) != 2 {
+			return "", "", fmt.Errorf("unrecognized remote URL: %s", urls[0])
+		}
+		path = parts[1]
+	default:
+		if idx := strings.Index(path, "://"); idx != -1 {
+			path = path[idx+len("://"):]
+		}
+		if idx := strings.Index(path, "/"); idx != -1 {
+			path = path[idx+1:]
+		} else {
+			return "", "", fmt.Errorf("unrecognized remote URL: %s", urls[0])
+		}
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unrecognized remote URL: %s", urls[0])
+	}
+	return parts[0], parts[1], nil
+}
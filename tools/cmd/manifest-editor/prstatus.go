@@ -0,0 +1,91 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/forge"
+)
+
+// prStatusCacheTTL bounds how long an open-PR lookup for a manifest
+// path is reused before the forge is queried again - long enough to
+// avoid hammering the forge's API every time a manifest list renders,
+// short enough that a PR opened or merged moments ago shows up without
+// restarting the server.
+const prStatusCacheTTL = 30 * time.Second
+
+type prStatusCacheEntry struct {
+	prs       []forge.PRRef
+	fetchedAt time.Time
+}
+
+var (
+	prStatusCacheMu sync.Mutex
+	prStatusCache   = make(map[string]prStatusCacheEntry)
+)
+
+// openPRsForManifestPath returns the open pull/merge requests touching
+// manifestPath (relative to the manifests directory, e.g.
+// "includes/phishing/urlhaus.json"), cached per owner/repo/path for
+// prStatusCacheTTL.
+func openPRsForManifestPath(ctx context.Context, manifestPath string) ([]forge.PRRef, error) {
+	provider, owner, repo, err := newForgeProvider(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	key := owner + "/" + repo + "/" + manifestPath
+
+	prStatusCacheMu.Lock()
+	if entry, ok := prStatusCache[key]; ok && time.Since(entry.fetchedAt) < prStatusCacheTTL {
+		prStatusCacheMu.Unlock()
+		return entry.prs, nil
+	}
+	prStatusCacheMu.Unlock()
+
+	prs, err := provider.ListOpenPRsForPath(ctx, owner, repo, "manifests/"+manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	prStatusCacheMu.Lock()
+	prStatusCache[key] = prStatusCacheEntry{prs: prs, fetchedAt: time.Now()}
+	prStatusCacheMu.Unlock()
+
+	return prs, nil
+}
+
+// handlePRStatus serves GET /api/pr/status?file=<manifest path>,
+// reporting the open pull/merge requests that touch it - so a user
+// editing a manifest can see one's already in flight before opening a
+// conflicting PR of their own.
+func handlePRStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file := r.URL.Query().Get("file")
+	if file == "" {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+
+	prs, err := openPRsForManifestPath(r.Context(), file)
+	if err != nil {
+		http.Error(w, "Failed to list open pull requests: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]interface{}{"open_prs": prs})
+}
+
+func scopeForPRStatusRequest(r *http.Request) (authz.Scope, error) {
+	return authz.ScopeManifestRead, nil
+}
@@ -5,11 +5,14 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"embed"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -25,9 +28,15 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/gemini/dnshield/internal/cfpref"
+	"github.com/gemini/dnshield/internal/managedconfig"
+	"github.com/gemini/dnshield/internal/meditor/authz"
+	"github.com/gemini/dnshield/internal/meditor/forge"
+	"github.com/gemini/dnshield/internal/meditor/policy"
+	"github.com/gemini/dnshield/internal/meditor/session"
+	"github.com/gemini/dnshield/internal/meditor/watch"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v66/github"
 	"golang.org/x/oauth2"
@@ -41,10 +50,16 @@ type Config struct {
 
 type AppConfig struct {
 	RepoPath string `json:"repo_path"`
+	// ForgeType selects which forge PR creation targets: "github"
+	// (default), "gitlab", "gitea", "bitbucket", or "azuredevops".
+	ForgeType string `json:"forge_type,omitempty"`
 }
 
 type ConfigRequest struct {
 	RepoPath string `json:"repo_path"`
+	// ForgeType is optional; an empty value leaves the current setting
+	// (or the "github" default) unchanged.
+	ForgeType string `json:"forge_type,omitempty"`
 }
 
 type PathSuggestion struct {
@@ -59,6 +74,12 @@ type DomainRequest struct {
 	Domains      []string `json:"domains"`
 	Category     string   `json:"category,omitempty"`  // for new manifests: "global", "domain", "group", "phishing", "team"
 	RuleType     string   `json:"rule_type,omitempty"` // ruleTypeAllow or ruleTypeBlock
+	// Fingerprint is the SHA-256 (hex) of the manifest file as last read
+	// by the caller; required for "add"/"remove" so a write against a
+	// manifest someone else has since changed is rejected as a 409
+	// instead of silently overwriting it. Not required for "create",
+	// since there's no existing file to drift.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 type GitHubAppConfig struct {
@@ -81,11 +102,33 @@ type PRRequest struct {
 	PRTitle       string     `json:"pr_title"`
 	PRBody        string     `json:"pr_body"`
 	Files         []FileSpec `json:"files"`
+
+	// ManifestName, Category, RuleType, AddedDomains, RemovedDomains, and
+	// TotalDomains mirror the result map addDomainsToManifest/
+	// removeDomainsFromManifest already return, so a caller that just
+	// mutated a manifest's domains can pass that same result straight
+	// through here to drive the default/templated PR title and body
+	// instead of composing one of its own.
+	ManifestName   string   `json:"manifest_name,omitempty"`
+	Category       string   `json:"category,omitempty"`
+	RuleType       string   `json:"rule_type,omitempty"`
+	AddedDomains   []string `json:"added_domains,omitempty"`
+	RemovedDomains []string `json:"removed_domains,omitempty"`
+	TotalDomains   int      `json:"total_domains,omitempty"`
+
+	// UserHandle is set server-side from the caller's session, not from
+	// the request body, so a template can't be made to credit a PR to
+	// someone other than whoever actually opened it.
+	UserHandle string `json:"-"`
 }
 
 type FileSpec struct {
 	Path          string `json:"path"`
 	ContentBase64 string `json:"content_base64"`
+	// Fingerprint, if set, is the SHA-256 (hex) the caller last read
+	// Path's content as; handleGitHubPR rejects the whole PR if any
+	// file's fingerprint has drifted since.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 type PRResponse struct {
@@ -104,6 +147,19 @@ type TokenService struct {
 	cacheToken     string
 	cacheExpiry    time.Time
 	apiBase        string
+	rateLimit      *forge.RateLimitMetrics
+}
+
+// rateLimitTransport wraps base (http.DefaultTransport if nil) with
+// GitHub's rate-limit conventions, sharing ts.rateLimit across every
+// client ts or a factory built from it constructs so the installation's
+// request budget is tracked across the whole subsystem rather than per
+// client.
+func (ts *TokenService) rateLimitTransport(base http.RoundTripper) http.RoundTripper {
+	if ts.rateLimit == nil {
+		ts.rateLimit = &forge.RateLimitMetrics{}
+	}
+	return &forge.RateLimitTransport{Base: base, Metrics: ts.rateLimit}
 }
 
 type SearchRequest struct {
@@ -136,16 +192,28 @@ func writeJSON(w http.ResponseWriter, payload interface{}) {
 type ManifestData struct {
 	Manifests []string               `json:"manifests"`
 	Raw       map[string]interface{} `json:"raw,omitempty"`
+	// Fingerprint is the SHA-256 (hex) of the manifest file's on-disk
+	// bytes, echoed back so the caller can round-trip it into a later
+	// write as an optimistic-concurrency check.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 type ManifestItem struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
 	Path string `json:"path,omitempty"`
+	// OpenPRs lists currently open pull/merge requests that touch this
+	// manifest, so a user can see one's already in flight before
+	// opening a conflicting one. Populated best-effort: a forge error
+	// leaves it empty rather than failing the whole listing.
+	OpenPRs []forge.PRRef `json:"open_prs,omitempty"`
 }
 
 type UpdateRequest struct {
 	Manifests []string `json:"manifests"`
+	// Fingerprint must match the current file's fingerprint (as
+	// returned by a prior GET) or the write is rejected with 409.
+	Fingerprint string `json:"fingerprint"`
 }
 
 type LegacyPRRequest struct {
@@ -175,9 +243,14 @@ var (
 	tokenService       *TokenService
 	userMapping        = make(map[string][]Machine)       // Changed to store multiple machines per user
 	inheritedManifests = make(map[string]map[string]bool) // Cache inherited manifests
-	prefDomain         = "com.dnshield.manifest-editor"
-	sessions           = make(map[string]*UserSession)
-	csrfStates         = make(map[string]string) // state -> sessionID
+	// cacheMu guards userMapping and inheritedManifests, which are
+	// written lazily by request handlers and cleared by manifestWatcher
+	// whenever the manifests directory changes on disk.
+	cacheMu         sync.RWMutex
+	prefDomain      = "com.dnshield.manifest-editor"
+	managedSource   = managedconfig.New(prefDomain)
+	sessionStore    session.Store
+	manifestWatcher *watch.Watcher
 )
 
 const (
@@ -186,14 +259,6 @@ const (
 	ruleTypeBlock        = "block"
 )
 
-// Lightweight session storage for local tool use.
-type UserSession struct {
-	AccessToken string
-	Login       string
-	Name        string
-	ExpiresAt   time.Time
-}
-
 func init() { //nolint:gochecknoinits // Manifest editor preloads configuration before main to configure handlers.
 	// Load environment variables
 	loadEnvFile()
@@ -203,6 +268,20 @@ func init() { //nolint:gochecknoinits // Manifest editor preloads configuration
 		log.Printf("Warning: GitHub App not configured: %v", err)
 	}
 
+	// Initialize GitLab/Gitea/Bitbucket/Azure DevOps connection details,
+	// for deployments whose forge_type selects one of them instead of GitHub.
+	initForgeConfig()
+
+	// Initialize the signed, persisted session store
+	if err := initSessionStore(); err != nil {
+		log.Fatalf("Failed to initialize session store: %v", err)
+	}
+
+	// Initialize the append-only audit log
+	if err := initAuditLogger(); err != nil {
+		log.Fatalf("Failed to initialize audit log: %v", err)
+	}
+
 	// Load saved repository path first
 	if err := loadAppConfig(); err == nil && appConfig.RepoPath != "" {
 		// Use saved path if it's still valid
@@ -224,6 +303,27 @@ func init() { //nolint:gochecknoinits // Manifest editor preloads configuration
 	}
 }
 
+// initSessionStore opens the on-disk session store, creating its BoltDB
+// file and HMAC signing key under the same config directory as
+// config.json if they don't exist yet.
+func initSessionStore() error {
+	usr, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	configDir := filepath.Join(usr.HomeDir, ".config", "dnshield-manifest-editor")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		return err
+	}
+
+	sessionStore, err = session.Open(
+		filepath.Join(configDir, "sessions.db"),
+		filepath.Join(configDir, "session.key"),
+	)
+	return err
+}
+
 // getConfigPath returns the path to the app configuration file.
 func getConfigPath() (string, error) {
 	usr, err := user.Current()
@@ -291,21 +391,38 @@ func isValidRepoPath(path string) bool {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runAuditVerify())
+	}
+
+	startManifestWatcher(config.ManifestsDir)
+	startFeedsScheduler(config.BaseDir)
+
 	// Setup routes
-	http.HandleFunc("/api/search", corsMiddleware(requireConfig(handleSearch)))
-	http.HandleFunc("/api/manifests/", corsMiddleware(requireConfig(handleManifests)))
-	http.HandleFunc("/api/manifests/available", corsMiddleware(requireConfig(handleAvailableManifests)))
-	http.HandleFunc("/api/manifest/view/", corsMiddleware(requireConfig(handleViewManifest)))
-	http.HandleFunc("/api/domains", corsMiddleware(requireConfig(handleDomains)))
-	http.HandleFunc("/api/pull-request", corsMiddleware(requireConfig(handlePullRequest)))
-	http.HandleFunc("/api/pr-from-json-edits", corsMiddleware(requireConfig(handleGitHubPR)))
+	http.HandleFunc("/api/search", corsMiddleware(requireConfig(requireScope(scopeForRead, handleSearch))))
+	http.HandleFunc("/api/manifests/", corsMiddleware(requireConfig(requireScope(scopeForManifestsRequest, handleManifests))))
+	http.HandleFunc("/api/manifests/available", corsMiddleware(requireConfig(requireScope(scopeForRead, handleAvailableManifests))))
+	http.HandleFunc("/api/manifest/view/", corsMiddleware(requireConfig(requireScope(scopeForRead, handleViewManifest))))
+	http.HandleFunc("/api/manifest/lint", corsMiddleware(requireConfig(requireScope(scopeForRead, handleManifestLint))))
+	http.HandleFunc("/api/domains", corsMiddleware(requireConfig(requireScope(scopeForDomainsRequest, handleDomains))))
+	http.HandleFunc("/api/pull-request", corsMiddleware(requireConfig(requireScope(scopeForLegacyPRRequest, handlePullRequest))))
+	http.HandleFunc("/api/pr-from-json-edits", corsMiddleware(requireConfig(requireScope(scopeForGitHubPRRequest, handleGitHubPR))))
+	// Git Smart HTTP transport so agents can clone/pull (and, with write
+	// scope, push) the manifests repo directly instead of needing their
+	// own GitHub credentials.
+	http.HandleFunc("/git/manifests.git/", requireConfig(handleGitSmartHTTP))
+	http.HandleFunc("/api/auth/device-token", corsMiddleware(handleDeviceToken))
 	// Auth endpoints
 	http.HandleFunc("/api/auth/status", corsMiddleware(handleAuthStatus))
 	http.HandleFunc("/api/auth/login", corsMiddleware(handleAuthLogin))
 	http.HandleFunc("/api/auth/callback", corsMiddleware(handleAuthCallback))
 	http.HandleFunc("/api/auth/logout", corsMiddleware(handleAuthLogout))
+	http.HandleFunc("/api/auth/scopes", corsMiddleware(handleAuthScopes))
 	http.HandleFunc("/api/config", corsMiddleware(handleConfig))
 	http.HandleFunc("/api/health", corsMiddleware(handleHealth))
+	http.HandleFunc("/api/audit", corsMiddleware(requireConfig(requireScope(scopeForAuditRequest, handleAudit))))
+	http.HandleFunc("/api/feeds", corsMiddleware(requireConfig(requireScope(scopeForFeedsRequest, handleFeeds))))
+	http.HandleFunc("/api/pr/status", corsMiddleware(requireConfig(requireScope(scopeForPRStatusRequest, handlePRStatus))))
 
 	// Serve embedded static files
 	staticFS, err := fs.Sub(staticFiles, "frontend")
@@ -367,9 +484,20 @@ func handleAuthLogin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "GitHub App OAuth not configured", http.StatusServiceUnavailable)
 		return
 	}
-	sid := ensureSession(w, r)
-	state := randString(24)
-	csrfStates[state] = sid
+	sid, err := ensureSession(w, r)
+	if err != nil {
+		http.Error(w, "Failed to create session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state, err := session.GenerateToken(24)
+	if err != nil {
+		http.Error(w, "Failed to generate OAuth state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := sessionStore.PutState(state, sid); err != nil {
+		http.Error(w, "Failed to record OAuth state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	redirectURL := oauthRedirectURL(r)
 	q := url.Values{}
 	q.Set("client_id", ghAppConfig.ClientID)
@@ -392,12 +520,15 @@ func handleAuthCallback(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing code/state", http.StatusBadRequest)
 		return
 	}
-	sid, ok := csrfStates[state]
+	sid, ok, err := sessionStore.TakeState(state)
+	if err != nil {
+		http.Error(w, "Failed to validate state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	if !ok {
 		http.Error(w, "Invalid state", http.StatusBadRequest)
 		return
 	}
-	delete(csrfStates, state)
 
 	form := url.Values{}
 	form.Set("client_id", ghAppConfig.ClientID)
@@ -437,7 +568,12 @@ func handleAuthCallback(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to fetch user profile", http.StatusBadGateway)
 		return
 	}
-	sessions[sid] = &UserSession{AccessToken: tokenResp.AccessToken, Login: user.GetLogin(), Name: user.GetName(), ExpiresAt: time.Now().Add(8 * time.Hour)}
+	sess := &session.Session{ID: sid, AccessToken: tokenResp.AccessToken, Login: user.GetLogin(), Name: user.GetName(), ExpiresAt: time.Now().Add(8 * time.Hour)}
+	if err := sessionStore.Save(sess); err != nil {
+		http.Error(w, "Failed to save session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logAudit(r, sess, "auth.login", "", "", 0, "", "")
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
@@ -484,41 +620,113 @@ func oauthRedirectURL(r *http.Request) string {
 }
 
 func handleAuthLogout(w http.ResponseWriter, r *http.Request) {
-	if c, err := r.Cookie("me_session"); err == nil {
-		delete(sessions, c.Value)
-		http.SetCookie(w, &http.Cookie{Name: "me_session", Value: "", Path: "/", Expires: time.Unix(0, 0), MaxAge: -1})
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		if id, ok := sessionStore.Verify(c.Value); ok {
+			_ = sessionStore.Delete(id)
+		}
+		http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "", Path: "/", Expires: time.Unix(0, 0), MaxAge: -1})
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// sessionCookieName is the cookie the signed session ID travels in.
+const sessionCookieName = "me_session"
+
+// sessionCookieLifetime bounds how long a session cookie, and the
+// session it names, stays valid before the user has to log in again.
+const sessionCookieLifetime = 8 * time.Hour
+
 // Session helpers.
-func ensureSession(w http.ResponseWriter, r *http.Request) string {
-	if c, err := r.Cookie("me_session"); err == nil && c.Value != "" {
-		return c.Value
+func ensureSession(w http.ResponseWriter, r *http.Request) (string, error) {
+	if c, err := r.Cookie(sessionCookieName); err == nil && c.Value != "" {
+		if id, ok := sessionStore.Verify(c.Value); ok {
+			if _, found, err := sessionStore.Get(id); err == nil && found {
+				return id, nil
+			}
+		}
 	}
-	sid := randString(32)
-	http.SetCookie(w, &http.Cookie{Name: "me_session", Value: sid, Path: "/", HttpOnly: true})
-	return sid
-}
 
-func currentSession(r *http.Request) *UserSession {
-	c, err := r.Cookie("me_session")
+	sid, err := sessionStore.New()
 	if err != nil {
-		return nil
+		return "", err
 	}
-	return sessions[c.Value]
+	setSessionCookie(w, sid)
+	return sid, nil
 }
 
-func randString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[time.Now().UnixNano()%int64(len(letters))]
+func setSessionCookie(w http.ResponseWriter, sid string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    sessionStore.Sign(sid),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(sessionCookieLifetime.Seconds()),
+	})
+}
+
+func currentSession(r *http.Request) *session.Session {
+	c, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
 	}
-	return string(b)
+	id, ok := sessionStore.Verify(c.Value)
+	if !ok {
+		return nil
+	}
+	sess, found, err := sessionStore.Get(id)
+	if err != nil || !found {
+		return nil
+	}
+	return sess
+}
+
+// invalidateCaches clears userMapping and inheritedManifests so the next
+// request rebuilds them from the current on-disk files. userMapping is
+// derived by scanning every machine manifest rather than keyed by a
+// single file, so a targeted per-file eviction can't keep it correct;
+// a full clear is cheap enough to redo lazily on the next search.
+func invalidateCaches() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	userMapping = make(map[string][]Machine)
+	inheritedManifests = make(map[string]map[string]bool)
+	invalidateInheritanceGraph()
+}
+
+// invalidateManifestPath drops filePath's cached inherited-manifests
+// entry, used by saveManifest so a write made by this process is
+// reflected immediately instead of waiting for manifestWatcher's
+// debounce window to notice its own write.
+func invalidateManifestPath(filePath string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	delete(inheritedManifests, filePath)
+	invalidateInheritanceGraph()
+}
+
+// startManifestWatcher (re)points manifestWatcher at dir, closing any
+// previous watcher first (e.g. when the configured repository changes).
+func startManifestWatcher(dir string) {
+	if manifestWatcher != nil {
+		_ = manifestWatcher.Close()
+		manifestWatcher = nil
+	}
+	if dir == "" {
+		return
+	}
+	w, err := watch.Start(dir, invalidateCaches)
+	if err != nil {
+		log.Printf("Warning: failed to watch manifests directory: %v", err)
+		return
+	}
+	manifestWatcher = w
 }
 
 func loadUserMapping() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
 	if len(userMapping) > 0 {
 		return
 	}
@@ -624,6 +832,9 @@ func searchEntity(entityType, query string) SearchResult {
 	case "user":
 		loadUserMapping()
 
+		cacheMu.RLock()
+		defer cacheMu.RUnlock()
+
 		// Exact match
 		if machines, ok := userMapping[query]; ok && len(machines) > 0 {
 			// If multiple machines, return them all
@@ -745,6 +956,9 @@ func handleManifests(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		content := getManifestContent(path)
 		w.Header().Set("Content-Type", "application/json")
+		if content.Fingerprint != "" {
+			w.Header().Set("ETag", `"`+content.Fingerprint+`"`)
+		}
 		writeJSON(w, content)
 
 	case http.MethodPut:
@@ -753,12 +967,31 @@ func handleManifests(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if req.Fingerprint == "" {
+			http.Error(w, "fingerprint is required", http.StatusBadRequest)
+			return
+		}
 
-		if saveManifest(path, req.Manifests) {
-			w.Header().Set("Content-Type", "application/json")
-			writeJSON(w, map[string]bool{"success": true})
-		} else {
-			http.Error(w, "Failed to save", http.StatusInternalServerError)
+		var saveErr error
+		current, conflict, _ := DoLockedAction(path, req.Fingerprint, func() error {
+			if !saveManifest(path, req.Manifests) {
+				saveErr = errors.New("failed to save")
+			}
+			return saveErr
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case conflict:
+			w.Header().Set("ETag", `"`+current.Fingerprint+`"`)
+			w.WriteHeader(http.StatusConflict)
+			writeJSON(w, current)
+		case saveErr != nil:
+			http.Error(w, "Failed to save: "+saveErr.Error(), http.StatusInternalServerError)
+		default:
+			logAudit(r, currentSession(r), "manifest.save", path, fmt.Sprintf("%d included manifests", len(req.Manifests)), 0, "", req.Fingerprint)
+			w.Header().Set("ETag", `"`+current.Fingerprint+`"`)
+			writeJSON(w, map[string]any{"success": true, "fingerprint": current.Fingerprint})
 		}
 
 	default:
@@ -766,6 +999,60 @@ func handleManifests(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// fingerprintBytes returns the hex SHA-256 digest used as a manifest's
+// optimistic-concurrency fingerprint.
+func fingerprintBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestLocks guards concurrent reads/writes of the same manifest file
+// by its repo-relative path so a fingerprint check and the write it
+// gates can't race another request.
+var manifestLocks sync.Map // map[string]*sync.Mutex
+
+func manifestMutex(relPath string) *sync.Mutex {
+	v, _ := manifestLocks.LoadOrStore(relPath, &sync.Mutex{})
+	return v.(*sync.Mutex)
+}
+
+// DoLockedAction holds relPath's manifest lock, re-reads the file, and
+// compares its fingerprint against expectedFingerprint before running
+// fn - so a caller who fetched the manifest, let the user edit it, and
+// is now writing it back can detect that someone else changed it in the
+// meantime instead of silently clobbering their edit. An empty
+// expectedFingerprint skips the check (for a file with no prior version
+// to compare against). fn may be nil to
+// just perform the check, e.g. to validate a batch of files before a
+// multi-file PR commit.
+func DoLockedAction(relPath, expectedFingerprint string, fn func() error) (current ManifestData, conflict bool, err error) {
+	mu := manifestMutex(relPath)
+	mu.Lock()
+	defer mu.Unlock()
+
+	current = getManifestContent(relPath)
+	if expectedFingerprint != "" && current.Fingerprint != expectedFingerprint {
+		return current, true, nil
+	}
+	if fn != nil {
+		if err := fn(); err != nil {
+			return current, false, err
+		}
+		current = getManifestContent(relPath)
+	}
+	return current, false, nil
+}
+
+// relManifestPath converts an absolute manifest path back to the
+// repo-relative form used as a manifestMutex/DoLockedAction key.
+func relManifestPath(fullPath string) string {
+	rel, err := filepath.Rel(config.ManifestsDir, fullPath)
+	if err != nil {
+		return fullPath
+	}
+	return filepath.ToSlash(rel)
+}
+
 func getManifestContent(filePath string) ManifestData {
 	fullPath := filepath.Join(config.ManifestsDir, filePath)
 
@@ -773,10 +1060,11 @@ func getManifestContent(filePath string) ManifestData {
 	if err != nil {
 		return ManifestData{Manifests: []string{}}
 	}
+	fingerprint := fingerprintBytes(data)
 
 	var rawData map[string]interface{}
 	if err := json.Unmarshal(data, &rawData); err != nil {
-		return ManifestData{Manifests: []string{}}
+		return ManifestData{Manifests: []string{}, Fingerprint: fingerprint}
 	}
 
 	manifests := []string{}
@@ -805,11 +1093,14 @@ func getManifestContent(filePath string) ManifestData {
 
 	// Calculate all inherited manifests (including nested)
 	allInherited := getAllInheritedManifests(manifests)
+	cacheMu.Lock()
 	inheritedManifests[filePath] = allInherited
+	cacheMu.Unlock()
 
 	return ManifestData{
-		Manifests: manifests,
-		Raw:       rawData,
+		Manifests:   manifests,
+		Raw:         rawData,
+		Fingerprint: fingerprint,
 	}
 }
 
@@ -938,7 +1229,13 @@ func saveManifest(filePath string, manifests []string) bool {
 		return false
 	}
 
-	return os.WriteFile(fullPath, append(output, '\n'), 0o644) == nil //nolint:gosec // manifests remain world-readable within repository checkout
+	if err := os.WriteFile(fullPath, append(output, '\n'), 0o644); err != nil { //nolint:gosec // manifests remain world-readable within repository checkout
+		return false
+	}
+	// Invalidate synchronously so this process's own write is reflected
+	// immediately, rather than waiting out manifestWatcher's debounce.
+	invalidateManifestPath(filePath)
+	return true
 }
 
 func determineCatalogPath(manifest string) string {
@@ -981,10 +1278,13 @@ func handleAvailableManifests(w http.ResponseWriter, r *http.Request) {
 
 	manifests := getAvailableManifests()
 
+	cacheMu.RLock()
+	inherited := inheritedManifests[entityFile]
+	cacheMu.RUnlock()
+
 	// If we have inherited manifests for this entity, filter them out
-	if entityFile != "" && len(inheritedManifests[entityFile]) > 0 {
+	if entityFile != "" && len(inherited) > 0 {
 		filtered := []ManifestItem{}
-		inherited := inheritedManifests[entityFile]
 		currentManifests := getManifestContent(entityFile).Manifests
 
 		// Create a map of current team assignments for filtering
@@ -1011,10 +1311,29 @@ func handleAvailableManifests(w http.ResponseWriter, r *http.Request) {
 		manifests = filtered
 	}
 
+	populateOpenPRs(r.Context(), manifests)
+
 	w.Header().Set("Content-Type", "application/json")
 	writeJSON(w, map[string][]ManifestItem{"manifests": manifests})
 }
 
+// populateOpenPRs fills in each manifest's OpenPRs field in place. A
+// forge error for one manifest is logged and leaves that manifest's
+// OpenPRs empty rather than failing the whole listing.
+func populateOpenPRs(ctx context.Context, manifests []ManifestItem) {
+	for i, m := range manifests {
+		if m.Path == "" {
+			continue
+		}
+		prs, err := openPRsForManifestPath(ctx, m.Path+".json")
+		if err != nil {
+			log.Printf("Warning: failed to list open PRs for %s: %v", m.Path, err)
+			continue
+		}
+		manifests[i].OpenPRs = prs
+	}
+}
+
 func getAvailableManifests() []ManifestItem {
 	manifests := []ManifestItem{}
 
@@ -1039,163 +1358,27 @@ func getAvailableManifests() []ManifestItem {
 	return manifests
 }
 
-func handlePullRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req LegacyPRRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	result := createPullRequest(req.Branch, req.Title, req.Description, req.File)
-	w.Header().Set("Content-Type", "application/json")
-	writeJSON(w, result)
-}
-
-func createPullRequest(branch, title, description, filePath string) LegacyPRResponse {
-	// Change to repo directory
-	if err := os.Chdir(config.BaseDir); err != nil {
-		return LegacyPRResponse{Success: false, Error: err.Error()}
-	}
-
-	// Check if gh CLI is available first
-	if !isGHInstalled() {
-		return LegacyPRResponse{
-			Success: false,
-			Error:   "GitHub CLI (gh) is required but not installed. " + getGHInstallMessage(),
-		}
-	}
-
-	// Check if we have uncommitted changes for the specific file
-	cmd := exec.CommandContext(context.Background(), "git", "status", "--porcelain", filepath.Join("manifests", filePath)) //nolint:gosec // command arguments are controlled and limited to git operations within the repository
-	statusOutput, _ := cmd.Output()
-	if len(statusOutput) == 0 {
-		return LegacyPRResponse{
-			Success: false,
-			Error:   "No changes detected in the file. Please make sure you've saved changes first.",
-		}
-	}
-
-	// Create and switch to new branch, add changes, then use gh pr create
-	manifestPath := filepath.Join("manifests", filePath)
-
-	// Create new branch from main
-	cmd = exec.CommandContext(context.Background(), "git", "checkout", "-b", branch)
-	if err := cmd.Run(); err != nil {
-		return LegacyPRResponse{Success: false, Error: "Failed to create branch"}
-	}
-
-	// Add and commit the file changes
-	cmd = exec.CommandContext(context.Background(), "git", "add", manifestPath)
-	if err := cmd.Run(); err != nil {
-		return LegacyPRResponse{Success: false, Error: "Failed to stage changes"}
-	}
-
-	cmd = exec.CommandContext(context.Background(), "git", "commit", "-m", title)
-	if err := cmd.Run(); err != nil {
-		return LegacyPRResponse{Success: false, Error: "Failed to commit changes"}
-	}
-
-	// Use gh pr create which will push the branch and create PR
-	cmd = exec.CommandContext(context.Background(), "gh", "pr", "create",
-		"--title", title,
-		"--body", description,
-		"--base", "main",
-	)
-
-	output, err := cmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
-
-	if err == nil {
-		// Success! Extract PR URL from output and mark as ready
-		prURL := outputStr
-
-		// Convert from draft to ready
-		cmd = exec.CommandContext(context.Background(), "gh", "pr", "ready", prURL)
-		if err := cmd.Run(); err != nil {
-			log.Printf("failed to mark pull request ready: %v", err)
-		}
-
-		return LegacyPRResponse{
-			Success: true,
-			Branch:  branch,
-			Message: fmt.Sprintf("Pull request created successfully! View at: %s", prURL),
-		}
-	}
-
-	// Handle various gh pr create error cases
-	if strings.Contains(outputStr, "auth") || strings.Contains(outputStr, "authenticate") {
-		return LegacyPRResponse{
-			Success: false,
-			Error:   "GitHub CLI needs authentication. Run: gh auth login",
-		}
-	}
-
-	if strings.Contains(outputStr, "already exists") {
-		return LegacyPRResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Branch '%s' or PR already exists. Use a different branch name.", branch),
-		}
-	}
-
-	// Generic error with full output for debugging
-	return LegacyPRResponse{
-		Success: false,
-		Error:   fmt.Sprintf("Failed to create PR. gh error: %s", outputStr),
-	}
-}
-
-// Check if GitHub CLI is installed.
-func isGHInstalled() bool {
-	cmd := exec.CommandContext(context.Background(), "which", "gh")
-	err := cmd.Run()
-	return err == nil
-}
-
-// Get installation message for GitHub CLI.
-func getGHInstallMessage() string {
-	// Detect OS for platform-specific instructions
-	cmd := exec.CommandContext(context.Background(), "uname", "-s")
-	output, err := cmd.Output()
-	osName := "Linux"
-	if err == nil {
-		osName = strings.TrimSpace(string(output))
-	}
-
-	switch osName {
-	case "Darwin": // macOS
-		return "Tip: Install GitHub CLI for automatic PR creation:\n" +
-			"   brew install gh\n" +
-			"   gh auth login\n" +
-			"   More info: https://cli.github.com/manual/installation"
-	case "Linux":
-		return "Tip: Install GitHub CLI for automatic PR creation:\n" +
-			"   • Debian/Ubuntu: sudo apt install gh\n" +
-			"   • Fedora: sudo dnf install gh\n" +
-			"   • Arch: sudo pacman -S github-cli\n" +
-			"   Then run: gh auth login\n" +
-			"   More info: https://cli.github.com/manual/installation"
-	default:
-		return "Tip: Install GitHub CLI for automatic PR creation:\n" +
-			"   Download from: https://cli.github.com\n" +
-			"   Then run: gh auth login"
-	}
-}
-
 func handleConfig(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// Return current configuration status with suggestions
 		suggestions := generatePathSuggestions()
+		policyRuleCount := 0
+		if config.BaseDir != "" {
+			if pol, err := loadDnshieldPolicy(); err == nil {
+				policyRuleCount = len(pol.Rules)
+			} else {
+				log.Printf("[Config] WARNING: failed to load .dnshield.yml: %v", err)
+			}
+		}
 		w.Header().Set("Content-Type", "application/json")
 		writeJSON(w, map[string]interface{}{
-			"configured":  config.BaseDir != "",
-			"repo_path":   config.BaseDir,
-			"suggestions": suggestions,
+			"configured":      config.BaseDir != "",
+			"repo_path":       config.BaseDir,
+			"forge_type":      forgeType(),
+			"suggestions":     suggestions,
+			"policy_rules":    policyRuleCount,
+			"github_api_rate": githubAPIRateStatus(),
 		})
 
 	case http.MethodPost:
@@ -1214,6 +1397,9 @@ func handleConfig(w http.ResponseWriter, r *http.Request) {
 
 		// Update configuration
 		appConfig.RepoPath = req.RepoPath
+		if req.ForgeType != "" {
+			appConfig.ForgeType = req.ForgeType
+		}
 		config.BaseDir = req.RepoPath
 		config.ManifestsDir = filepath.Join(req.RepoPath, "manifests")
 
@@ -1222,9 +1408,11 @@ func handleConfig(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Warning: Failed to save config: %v", err)
 		}
 
-		// Clear cached data since we're switching repositories
-		userMapping = make(map[string][]Machine)
-		inheritedManifests = make(map[string]map[string]bool)
+		// Clear cached data since we're switching repositories, and
+		// re-point the filesystem watcher at the new manifests directory.
+		invalidateCaches()
+		startManifestWatcher(config.ManifestsDir)
+		startFeedsScheduler(config.BaseDir)
 
 		w.Header().Set("Content-Type", "application/json")
 		writeJSON(w, map[string]interface{}{
@@ -1260,21 +1448,77 @@ func handleDomains(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		result := createManifest(req.ManifestName, req.Category, req.Domains, ruleType)
+		if success, _ := result["success"].(bool); success {
+			logAudit(r, currentSession(r), "manifest.create", req.ManifestName, fmt.Sprintf("+%d domains", len(req.Domains)), 0, "", "")
+		}
 		w.Header().Set("Content-Type", "application/json")
 		writeJSON(w, result)
 	case "add":
-		result := addDomainsToManifest(req.ManifestName, req.Domains, req.RuleType)
-		w.Header().Set("Content-Type", "application/json")
-		writeJSON(w, result)
+		if req.Fingerprint == "" {
+			http.Error(w, "fingerprint is required", http.StatusBadRequest)
+			return
+		}
+		handleFingerprintedMutation(w, r, "domains.add", req.ManifestName, req.Fingerprint, func() map[string]interface{} {
+			return addDomainsToManifest(req.ManifestName, req.Domains, req.RuleType)
+		})
 	case "remove":
-		result := removeDomainsFromManifest(req.ManifestName, req.Domains)
-		w.Header().Set("Content-Type", "application/json")
-		writeJSON(w, result)
+		if req.Fingerprint == "" {
+			http.Error(w, "fingerprint is required", http.StatusBadRequest)
+			return
+		}
+		handleFingerprintedMutation(w, r, "domains.remove", req.ManifestName, req.Fingerprint, func() map[string]interface{} {
+			return removeDomainsFromManifest(req.ManifestName, req.Domains)
+		})
 	default:
 		http.Error(w, "Invalid action", http.StatusBadRequest)
 	}
 }
 
+// handleFingerprintedMutation resolves manifestName to its on-disk path,
+// locks it, verifies fingerprint still matches the current content, and
+// only then runs mutate - writing a 409 with the current manifest
+// content instead if it doesn't.
+func handleFingerprintedMutation(w http.ResponseWriter, r *http.Request, action, manifestName, fingerprint string, mutate func() map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	fullPath, err := manifestFilePath(manifestName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	relPath := relManifestPath(fullPath)
+
+	var result map[string]interface{}
+	current, conflict, _ := DoLockedAction(relPath, fingerprint, func() error {
+		result = mutate()
+		return nil
+	})
+
+	if conflict {
+		w.Header().Set("ETag", `"`+current.Fingerprint+`"`)
+		w.WriteHeader(http.StatusConflict)
+		writeJSON(w, current)
+		return
+	}
+	if success, _ := result["success"].(bool); success {
+		logAudit(r, currentSession(r), action, relPath, domainDiffSummary(result), 0, "", fingerprint)
+	}
+	writeJSON(w, result)
+}
+
+// domainDiffSummary renders a short human-readable summary of a
+// addDomainsToManifest/removeDomainsFromManifest result map, for the
+// audit log's diff_summary field.
+func domainDiffSummary(result map[string]interface{}) string {
+	if added, ok := result["added_domains"].([]string); ok && len(added) > 0 {
+		return fmt.Sprintf("+%d domains", len(added))
+	}
+	if removed, ok := result["removed_domains"].([]string); ok && len(removed) > 0 {
+		return fmt.Sprintf("-%d domains", len(removed))
+	}
+	return ""
+}
+
 func handleViewManifest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -1289,6 +1533,15 @@ func handleViewManifest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result := getManifestDetails(manifestName)
+	if success, _ := result["success"].(bool); success {
+		if path, ok := result["path"].(string); ok {
+			if prs, err := openPRsForManifestPath(r.Context(), relManifestPath(path)); err != nil {
+				log.Printf("Warning: failed to list open PRs for %s: %v", path, err)
+			} else {
+				result["open_prs"] = prs
+			}
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	writeJSON(w, result)
 }
@@ -1299,8 +1552,10 @@ func handleGitHubPR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if GitHub App is configured
-	if ghAppConfig == nil || tokenService == nil {
+	// GitHub is the only forge with its own App installation token; every
+	// other forge authenticates purely as the signed-in user, checked
+	// just below.
+	if forgeType() == "github" && (ghAppConfig == nil || tokenService == nil) {
 		http.Error(w, "GitHub App not configured", http.StatusServiceUnavailable)
 		return
 	}
@@ -1318,13 +1573,6 @@ func handleGitHubPR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Use defaults if not provided
-	if req.Owner == "" {
-		req.Owner = ghAppConfig.DefaultOwner
-	}
-	if req.Repo == "" {
-		req.Repo = ghAppConfig.DefaultRepo
-	}
 	if req.BaseBranch == "" {
 		req.BaseBranch = "main"
 	}
@@ -1332,19 +1580,117 @@ func handleGitHubPR(w http.ResponseWriter, r *http.Request) {
 		req.FeaturePrefix = "feature"
 	}
 
+	// scopeForGitHubPRRequest already gated pr:create; a PR can touch
+	// more than one manifest, so check write access to every file here
+	// once the full request body is available.
+	scopes, err := userScopes(r.Context(), sess)
+	if err != nil {
+		http.Error(w, "Failed to resolve permissions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, file := range req.Files {
+		required := authz.ScopeForManifestPath(strings.TrimPrefix(file.Path, "manifests/"))
+		if !authz.Allows(scopes, required) {
+			http.Error(w, fmt.Sprintf("Forbidden: missing scope %q for %s", required, file.Path), http.StatusForbidden)
+			return
+		}
+	}
+
+	// Snapshot every touched manifest's fingerprint before assembling the
+	// PR so a file someone else edited mid-flow aborts the whole PR
+	// instead of bundling a stale version of it alongside fresh ones.
+	for _, file := range req.Files {
+		if file.Fingerprint == "" {
+			continue
+		}
+		relPath := strings.TrimPrefix(file.Path, "manifests/")
+		current, conflict, err := DoLockedAction(relPath, file.Fingerprint, nil)
+		if err != nil {
+			http.Error(w, "Failed to verify "+file.Path+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if conflict {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			writeJSON(w, map[string]interface{}{
+				"error":       fmt.Sprintf("%s changed since you loaded it", file.Path),
+				"path":        file.Path,
+				"fingerprint": current.Fingerprint,
+			})
+			return
+		}
+	}
+
 	// Inject user token into context so actions are done on behalf of the user
+	req.UserHandle = sess.Login
 	ctx := withUserToken(r.Context(), sess.AccessToken)
 	result := createGitHubPR(ctx, req)
+	if result.Success {
+		paths := make([]string, len(req.Files))
+		for i, f := range req.Files {
+			paths[i] = f.Path
+		}
+		logAudit(r, sess, "pr.create", strings.Join(paths, ","), fmt.Sprintf("%d files", len(req.Files)), result.PRNumber, result.PRURL, "")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	writeJSON(w, result)
 }
 
+// createGitHubPR opens a pull/merge request for req against whichever
+// forge is configured (GitHub by default; GitLab, Gitea, Bitbucket, or
+// Azure DevOps if forge_type selects one), dispatching through the forge.Provider interface so the
+// branch/commit/PR steps below don't need to know which.
 func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
-	log.Printf("[PR] Starting GitHub PR creation for %s/%s", req.Owner, req.Repo)
-
-	// Initialize services
-	clientFactory := &GitHubClientFactory{tokenService: tokenService}
-	repoService := &RepoService{clientFactory: clientFactory}
+	userToken, _ := ctx.Value(ctxUserTokenKey{}).(string)
+	provider, defaultOwner, defaultRepo, err := newForgeProvider(ctx, userToken)
+	if err != nil {
+		return PRResponse{Success: false, Error: err.Error()}
+	}
+	if req.Owner == "" {
+		req.Owner = defaultOwner
+	}
+	if req.Repo == "" {
+		req.Repo = defaultRepo
+	}
+
+	log.Printf("[PR] Starting %s PR creation for %s/%s", forgeType(), req.Owner, req.Repo)
+
+	// .dnshield.yml governs reviewers/labels/base branch/title+body
+	// templates for the paths this PR touches; the first file's path
+	// picks the rule, since a PR's files normally share a category (a
+	// feed sync or a single manifest edit) and PRRequest has no
+	// per-file base branch of its own to split on.
+	var rule *policy.Rule
+	if pol, err := loadDnshieldPolicy(); err != nil {
+		log.Printf("[PR] WARNING: failed to load .dnshield.yml: %v", err)
+	} else if len(req.Files) > 0 {
+		rule = pol.RuleForPath(strings.TrimPrefix(req.Files[0].Path, "manifests/"))
+	}
+	if rule != nil && req.BaseBranch == "" && rule.BaseBranch != "" {
+		req.BaseBranch = rule.BaseBranch
+	}
+
+	templateData := policy.TemplateData{
+		ManifestName:   req.ManifestName,
+		Category:       req.Category,
+		RuleType:       req.RuleType,
+		AddedDomains:   req.AddedDomains,
+		RemovedDomains: req.RemovedDomains,
+		TotalDomains:   req.TotalDomains,
+		UserHandle:     req.UserHandle,
+		Timestamp:      time.Now(),
+		Diff:           domainsDiffText(req.AddedDomains, req.RemovedDomains),
+	}
+	if title, err := policy.RenderTitle(rule, templateData, req.PRTitle); err != nil {
+		log.Printf("[PR] WARNING: failed to render title template: %v", err)
+	} else {
+		req.PRTitle = title
+	}
+	if body, err := policy.RenderBody(rule, templateData, req.PRBody); err != nil {
+		log.Printf("[PR] WARNING: failed to render body template: %v", err)
+	} else {
+		req.PRBody = body
+	}
 
 	// Generate unique feature branch name
 	featureBranch := generateFeatureBranchName(req.FeaturePrefix, "manifest-editor")
@@ -1352,7 +1698,7 @@ func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
 
 	// Get base branch SHA
 	log.Printf("[PR] Getting base branch SHA for %s", req.BaseBranch)
-	baseSHA, err := repoService.GetBaseRef(ctx, req.Owner, req.Repo, req.BaseBranch)
+	baseSHA, err := provider.GetBaseRef(ctx, req.Owner, req.Repo, req.BaseBranch)
 	if err != nil {
 		log.Printf("[PR] ERROR: Failed to get base branch: %v", err)
 		return PRResponse{
@@ -1364,7 +1710,7 @@ func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
 
 	// Create feature branch
 	log.Printf("[PR] Creating feature branch: %s", featureBranch)
-	if err := repoService.CreateBranch(ctx, req.Owner, req.Repo, featureBranch, baseSHA); err != nil {
+	if err := provider.CreateBranch(ctx, req.Owner, req.Repo, featureBranch, baseSHA); err != nil {
 		log.Printf("[PR] ERROR: Failed to create branch: %v", err)
 		return PRResponse{
 			Success: false,
@@ -1375,13 +1721,17 @@ func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
 
 	// Commit files atomically
 	log.Printf("[PR] Committing %d files atomically", len(req.Files))
+	files := make([]forge.FileSpec, len(req.Files))
 	for i, file := range req.Files {
 		log.Printf("[PR] File %d: %s (%d bytes base64)", i+1, file.Path, len(file.ContentBase64))
+		files[i] = forge.FileSpec{Path: file.Path, ContentBase64: file.ContentBase64}
 	}
 
-	// Use App installation token for commit operations to obtain GitHub-signed (Verified) commits
+	// Use App installation token for commit operations to obtain
+	// GitHub-signed (Verified) commits; forges other than GitHub ignore
+	// this ctx value since provider.SignsCommits() is false for them.
 	ctxCommit := withCommitWithApp(ctx, true)
-	commitSHA, err := repoService.CommitFilesAtomic(ctxCommit, req.Owner, req.Repo, featureBranch, baseSHA, req.Files, req.CommitMessage)
+	commitSHA, err := provider.CommitFilesAtomic(ctxCommit, req.Owner, req.Repo, featureBranch, baseSHA, files, req.CommitMessage)
 	if err != nil {
 		log.Printf("[PR] ERROR: Failed to commit files: %v", err)
 		return PRResponse{
@@ -1393,7 +1743,7 @@ func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
 
 	// Create pull request as the authenticated user (on-behalf-of) using the user token
 	log.Printf("[PR] Creating pull request: %s -> %s", featureBranch, req.BaseBranch)
-	prNumber, prURL, err := repoService.OpenPR(ctx, req.Owner, req.Repo, req.PRTitle, req.PRBody, featureBranch, req.BaseBranch)
+	pr, err := provider.OpenPR(ctx, req.Owner, req.Repo, req.PRTitle, req.PRBody, featureBranch, req.BaseBranch)
 	if err != nil {
 		log.Printf("[PR] ERROR: Failed to create pull request: %v", err)
 		return PRResponse{
@@ -1401,23 +1751,49 @@ func createGitHubPR(ctx context.Context, req PRRequest) PRResponse {
 			Error:   fmt.Sprintf("Failed to create pull request: %v", err),
 		}
 	}
-	log.Printf("[PR] Pull request created successfully: #%d - %s", prNumber, prURL)
+	log.Printf("[PR] Pull request created successfully: #%d - %s", pr.Number, pr.URL)
+
+	if rule != nil && (len(rule.Reviewers) > 0 || len(rule.Labels) > 0) {
+		if err := provider.ApplyReviewAndLabels(ctx, req.Owner, req.Repo, pr.Number, rule.Reviewers, rule.Labels); err != nil {
+			log.Printf("[PR] WARNING: failed to apply reviewers/labels from .dnshield.yml: %v", err)
+		}
+	}
 
 	return PRResponse{
 		Success:   true,
 		Branch:    featureBranch,
 		CommitSHA: commitSHA,
-		PRNumber:  prNumber,
-		PRURL:     prURL,
+		PRNumber:  pr.Number,
+		PRURL:     pr.URL,
 	}
 }
 
 func handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	writeJSON(w, map[string]string{
+	resp := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().Format(time.RFC3339),
-	})
+	}
+
+	if manifestWatcher != nil {
+		lastReload, watchErr := manifestWatcher.Status()
+		resp["watcher"] = map[string]interface{}{
+			"active":      true,
+			"last_reload": lastReload.Format(time.RFC3339),
+			"error":       errString(watchErr),
+		}
+	} else {
+		resp["watcher"] = map[string]interface{}{"active": false}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, resp)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 // requireConfig is a middleware that ensures repository is configured.
@@ -1431,6 +1807,175 @@ func requireConfig(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// loadRolesConfig reads roles.yaml from the root of the configured
+// DNShield repository, where it's checked into git alongside the
+// manifests it governs.
+func loadRolesConfig() (*authz.Config, error) {
+	return authz.Load(filepath.Join(config.BaseDir, "roles.yaml"))
+}
+
+// loadDnshieldPolicy reads .dnshield.yml from the root of the configured
+// DNShield repository, where it's checked in alongside roles.yaml. It's
+// optional: a repo with no .dnshield.yml simply gets no reviewer/label/
+// template rules applied to its PRs.
+func loadDnshieldPolicy() (*policy.Config, error) {
+	path := filepath.Join(config.BaseDir, ".dnshield.yml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &policy.Config{}, nil
+	}
+	return policy.Load(path)
+}
+
+// githubAPIRateStatus reports the GitHub API request volume and
+// remaining rate-limit budget RateLimitTransport has observed so far,
+// for surfacing on /api/config alongside manifest-editor's other
+// operational counters. nil until the GitHub App has made its first
+// API call.
+func githubAPIRateStatus() map[string]interface{} {
+	if tokenService == nil || tokenService.rateLimit == nil {
+		return nil
+	}
+	requests, retries, remaining := tokenService.rateLimit.Snapshot()
+	return map[string]interface{}{
+		"requests":  requests,
+		"retries":   retries,
+		"remaining": remaining,
+	}
+}
+
+// userScopes resolves the scopes granted to sess, consulting roles.yaml
+// for both its direct login assignment and any team it belongs to.
+func userScopes(ctx context.Context, sess *session.Session) ([]authz.Scope, error) {
+	roles, err := loadRolesConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	gh := github.NewClient(nil).WithAuthToken(sess.AccessToken)
+	if ghAppConfig != nil && ghAppConfig.APIBase != defaultGitHubAPIBase && ghAppConfig.APIBase != "" {
+		gh, _ = gh.WithEnterpriseURLs(ghAppConfig.APIBase, ghAppConfig.APIBase)
+	}
+	teams, err := authz.FetchUserTeams(ctx, gh)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles.ScopesForUser(sess.Login, teams), nil
+}
+
+// requireScope gates handler behind the scope scopeFor derives from the
+// request, rejecting unauthenticated callers with 401 and authenticated
+// callers lacking that scope with 403. scopeFor's error is surfaced as a
+// 400, since it only fails on a malformed request body.
+func requireScope(scopeFor func(r *http.Request) (authz.Scope, error), handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := currentSession(r)
+		if sess == nil || sess.AccessToken == "" {
+			http.Error(w, "Authentication required. Please sign in via GitHub.", http.StatusUnauthorized)
+			return
+		}
+
+		required, err := scopeFor(r)
+		if err != nil {
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		scopes, err := userScopes(r.Context(), sess)
+		if err != nil {
+			http.Error(w, "Failed to resolve permissions: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !authz.Allows(scopes, required) {
+			http.Error(w, fmt.Sprintf("Forbidden: missing scope %q", required), http.StatusForbidden)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// bufferBody reads and restores r.Body so it can be inspected for
+// authorization purposes and then re-read in full by the handler.
+func bufferBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func scopeForRead(r *http.Request) (authz.Scope, error) {
+	return authz.ScopeManifestRead, nil
+}
+
+// scopeForManifestsRequest handles /api/manifests/<path>: GET only needs
+// read access, PUT needs write access to the specific manifest path.
+func scopeForManifestsRequest(r *http.Request) (authz.Scope, error) {
+	if r.Method != http.MethodPut {
+		return authz.ScopeManifestRead, nil
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/manifests/")
+	return authz.ScopeForManifestPath(path), nil
+}
+
+// scopeForDomainsRequest peeks at the request body to find which
+// manifest a domain create/add/remove targets.
+func scopeForDomainsRequest(r *http.Request) (authz.Scope, error) {
+	body, err := bufferBody(r)
+	if err != nil {
+		return "", err
+	}
+	var req DomainRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", err
+	}
+	return authz.ScopeForManifestPath(determineManifestPath(req.ManifestName)), nil
+}
+
+// scopeForLegacyPRRequest requires write access to the file the PR would
+// modify; pr:create is checked separately, inside handlePullRequest.
+func scopeForLegacyPRRequest(r *http.Request) (authz.Scope, error) {
+	body, err := bufferBody(r)
+	if err != nil {
+		return "", err
+	}
+	var req LegacyPRRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", err
+	}
+	return authz.ScopeForManifestPath(req.File), nil
+}
+
+// scopeForGitHubPRRequest requires pr:create; per-file write scopes for
+// req.Files are checked again inside handleGitHubPR once the full
+// request is available, since a PR can touch more than one manifest.
+func scopeForGitHubPRRequest(r *http.Request) (authz.Scope, error) {
+	if _, err := bufferBody(r); err != nil {
+		return "", err
+	}
+	return authz.ScopePRCreate, nil
+}
+
+func handleAuthScopes(w http.ResponseWriter, r *http.Request) {
+	sess := currentSession(r)
+	if sess == nil || sess.AccessToken == "" {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, map[string]any{"scopes": []string{}})
+		return
+	}
+
+	scopes, err := userScopes(r.Context(), sess)
+	if err != nil {
+		http.Error(w, "Failed to resolve permissions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, map[string]any{"scopes": scopes})
+}
+
 // generatePathSuggestions detects git repository using git rev-parse.
 func generatePathSuggestions() []PathSuggestion {
 	suggestions := []PathSuggestion{}
@@ -1518,7 +2063,7 @@ func loadEnvFromFile() {
 	}
 }
 
-// loadEnvFromPreferences loads from macOS preferences domain using cfpref.
+// loadEnvFromPreferences loads from the managed configuration domain.
 func loadEnvFromPreferences() {
 	// List of environment variables to check in preferences
 	envVars := []string{
@@ -1535,8 +2080,12 @@ func loadEnvFromPreferences() {
 	}
 
 	for _, envVar := range envVars {
-		// Try to read from preferences using cfpref with type information
-		value, valueType := cfpref.CFPreferencesCopyAppValueAndType(envVar, prefDomain)
+		// Try to read from the managed configuration source with level information
+		value, level, err := managedSource.Value(envVar)
+		if err != nil {
+			log.Printf("[ENV] Failed to read %s: %v", envVar, err)
+			continue
+		}
 		if value != nil {
 			var strValue string
 
@@ -1552,13 +2101,13 @@ func loadEnvFromPreferences() {
 				// Sometimes numbers come back as float64
 				strValue = strconv.FormatInt(int64(v), 10)
 			default:
-				log.Printf("[ENV] Unexpected type for %s: %T (cfpref type: %s)", envVar, value, valueType)
+				log.Printf("[ENV] Unexpected type for %s: %T (level: %s)", envVar, value, level)
 				continue
 			}
 
 			if strValue != "" {
 				os.Setenv(envVar, strValue)
-				log.Printf("[ENV] Loaded %s from macOS preferences (cfpref type: %s, go type: %T, value: %s)", envVar, valueType, value, strValue)
+				log.Printf("[ENV] Loaded %s from managed configuration (level: %s, go type: %T, value: %s)", envVar, level, value, strValue)
 			}
 		}
 	}
@@ -1713,10 +2262,11 @@ func (ts *TokenService) InstallationToken(ctx context.Context) (string, error) {
 			Source: oauth2.StaticTokenSource(&oauth2.Token{
 				AccessToken: jwtToken,
 			}),
+			Base: ts.rateLimitTransport(nil),
 		},
 	})
 
-	client := github.NewClient(nil).WithAuthToken(jwtToken)
+	client := github.NewClient(&http.Client{Transport: ts.rateLimitTransport(nil)}).WithAuthToken(jwtToken)
 	if ts.apiBase != defaultGitHubAPIBase {
 		client, _ = client.WithEnterpriseURLs(ts.apiBase, ts.apiBase)
 	}
@@ -1742,44 +2292,36 @@ type GitHubClientFactory struct {
 	tokenService *TokenService
 }
 
-// NewClient creates a new GitHub client with installation token.
+// NewClient creates a new GitHub client authenticated with Token's
+// result.
 func (factory *GitHubClientFactory) NewClient(ctx context.Context) (*github.Client, error) {
-	// If this request forces App token (for Verified commits), use installation token regardless of user token
-	if forceApp, ok := ctx.Value(ctxCommitWithAppKey{}).(bool); ok && forceApp {
-		token, err := factory.tokenService.InstallationToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		client := github.NewClient(nil).WithAuthToken(token)
-		if factory.tokenService.apiBase != defaultGitHubAPIBase {
-			client, _ = client.WithEnterpriseURLs(factory.tokenService.apiBase, factory.tokenService.apiBase)
-		}
-		return client, nil
-	}
-
-	// Otherwise, prefer user token (on behalf of the user)
-	if tok, ok := ctx.Value(ctxUserTokenKey{}).(string); ok && tok != "" {
-		client := github.NewClient(nil).WithAuthToken(tok)
-		if factory.tokenService.apiBase != defaultGitHubAPIBase {
-			client, _ = client.WithEnterpriseURLs(factory.tokenService.apiBase, factory.tokenService.apiBase)
-		}
-		return client, nil
-	}
-
-	// Fallback to installation token
-	token, err := factory.tokenService.InstallationToken(ctx)
+	token, err := factory.Token(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	client := github.NewClient(nil).WithAuthToken(token)
+	client := github.NewClient(&http.Client{Transport: factory.tokenService.rateLimitTransport(nil)}).WithAuthToken(token)
 	if factory.tokenService.apiBase != defaultGitHubAPIBase {
 		client, _ = client.WithEnterpriseURLs(factory.tokenService.apiBase, factory.tokenService.apiBase)
 	}
-
 	return client, nil
 }
 
+// Token resolves the access token a call against ctx should authenticate
+// with: the App installation token if the caller forced it (for Verified
+// commits), else the signed-in user's own token if one is set, else the
+// installation token as the default. CommitFilesAtomic's clone-mode path
+// uses this directly for git-over-HTTPS auth, the same precedence
+// NewClient applies for API calls.
+func (factory *GitHubClientFactory) Token(ctx context.Context) (string, error) {
+	if forceApp, ok := ctx.Value(ctxCommitWithAppKey{}).(bool); ok && forceApp {
+		return factory.tokenService.InstallationToken(ctx)
+	}
+	if tok, ok := ctx.Value(ctxUserTokenKey{}).(string); ok && tok != "" {
+		return tok, nil
+	}
+	return factory.tokenService.InstallationToken(ctx)
+}
+
 // Context key for injecting user access token.
 type ctxUserTokenKey struct{}
 
@@ -1794,156 +2336,6 @@ func withCommitWithApp(ctx context.Context, force bool) context.Context {
 	return context.WithValue(ctx, ctxCommitWithAppKey{}, force)
 }
 
-// RepoService handles repository operations.
-type RepoService struct {
-	clientFactory *GitHubClientFactory
-}
-
-// GetBaseRef returns the base branch commit SHA.
-func (rs *RepoService) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
-	client, err := rs.clientFactory.NewClient(ctx)
-	if err != nil {
-		return "", err
-	}
-
-	ref, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+baseBranch)
-	if err != nil {
-		return "", fmt.Errorf("failed to get base ref: %w", err)
-	}
-
-	return ref.Object.GetSHA(), nil
-}
-
-// CreateBranch creates a new feature branch from base SHA.
-func (rs *RepoService) CreateBranch(ctx context.Context, owner, repo, featureBranch, baseSHA string) error {
-	client, err := rs.clientFactory.NewClient(ctx)
-	if err != nil {
-		return err
-	}
-
-	ref := &github.Reference{
-		Ref: github.String("refs/heads/" + featureBranch),
-		Object: &github.GitObject{
-			SHA: github.String(baseSHA),
-		},
-	}
-
-	_, _, err = client.Git.CreateRef(ctx, owner, repo, ref)
-	if err != nil {
-		return fmt.Errorf("failed to create branch: %w", err)
-	}
-
-	return nil
-}
-
-// CommitFilesAtomic creates blobs, tree, commit, and updates ref atomically.
-func (rs *RepoService) CommitFilesAtomic(ctx context.Context, owner, repo, featureBranch, baseSHA string, files []FileSpec, message string) (string, error) {
-	log.Printf("[COMMIT] Starting atomic commit for %s/%s on branch %s", owner, repo, featureBranch)
-
-	client, err := rs.clientFactory.NewClient(ctx)
-	if err != nil {
-		log.Printf("[COMMIT] ERROR: Failed to create GitHub client: %v", err)
-		return "", err
-	}
-	log.Printf("[COMMIT] GitHub client created successfully")
-
-	// Get base commit to get base tree SHA
-	log.Printf("[COMMIT] Getting base commit: %s", baseSHA)
-	baseCommit, _, err := client.Git.GetCommit(ctx, owner, repo, baseSHA)
-	if err != nil {
-		log.Printf("[COMMIT] ERROR: Failed to get base commit: %v", err)
-		return "", fmt.Errorf("failed to get base commit: %w", err)
-	}
-	log.Printf("[COMMIT] Base commit tree SHA: %s", baseCommit.Tree.GetSHA())
-
-	// Create blobs for all files
-	var treeEntries []*github.TreeEntry
-	log.Printf("[COMMIT] Creating %d blobs", len(files))
-
-	for i, file := range files {
-		log.Printf("[COMMIT] Creating blob %d/%d for file: %s", i+1, len(files), file.Path)
-
-		// Create blob
-		blob, resp, err := client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
-			Content:  github.String(file.ContentBase64),
-			Encoding: github.String("base64"),
-		})
-		if err != nil {
-			log.Printf("[COMMIT] ERROR: Failed to create blob for %s (status: %d): %v", file.Path, resp.StatusCode, err)
-			return "", fmt.Errorf("failed to create blob for %s: %w", file.Path, err)
-		}
-		log.Printf("[COMMIT] Blob created for %s: %s", file.Path, blob.GetSHA())
-
-		// Add tree entry
-		treeEntries = append(treeEntries, &github.TreeEntry{
-			Path: github.String(file.Path),
-			Mode: github.String("100644"),
-			Type: github.String("blob"),
-			SHA:  github.String(blob.GetSHA()),
-		})
-	}
-
-	// Create tree
-	log.Printf("[COMMIT] Creating tree with base tree: %s", baseCommit.Tree.GetSHA())
-	tree, resp, err := client.Git.CreateTree(ctx, owner, repo, baseCommit.Tree.GetSHA(), treeEntries)
-	if err != nil {
-		log.Printf("[COMMIT] ERROR: Failed to create tree (status: %d): %v", resp.StatusCode, err)
-		return "", fmt.Errorf("failed to create tree: %w", err)
-	}
-	log.Printf("[COMMIT] Tree created successfully: %s", tree.GetSHA())
-
-	// Create commit
-	log.Printf("[COMMIT] Creating commit with message: %s", message)
-	commit, resp, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
-		Message: github.String(message),
-		Tree:    tree,
-		Parents: []*github.Commit{baseCommit},
-	}, &github.CreateCommitOptions{})
-	if err != nil {
-		log.Printf("[COMMIT] ERROR: Failed to create commit (status: %d): %v", resp.StatusCode, err)
-		return "", fmt.Errorf("failed to create commit: %w", err)
-	}
-	log.Printf("[COMMIT] Commit created successfully: %s", commit.GetSHA())
-
-	// Update branch ref
-	log.Printf("[COMMIT] Updating branch ref: refs/heads/%s", featureBranch)
-	ref := &github.Reference{
-		Ref: github.String("refs/heads/" + featureBranch),
-		Object: &github.GitObject{
-			SHA: commit.SHA,
-		},
-	}
-
-	_, resp, err = client.Git.UpdateRef(ctx, owner, repo, ref, false)
-	if err != nil {
-		log.Printf("[COMMIT] ERROR: Failed to update ref (status: %d): %v", resp.StatusCode, err)
-		return "", fmt.Errorf("failed to update ref: %w", err)
-	}
-	log.Printf("[COMMIT] Branch ref updated successfully")
-
-	return commit.GetSHA(), nil
-}
-
-// OpenPR creates a pull request.
-func (rs *RepoService) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (int, string, error) {
-	client, err := rs.clientFactory.NewClient(ctx)
-	if err != nil {
-		return 0, "", err
-	}
-
-	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
-		Title: github.String(title),
-		Head:  github.String(head),
-		Base:  github.String(base),
-		Body:  github.String(body),
-	})
-	if err != nil {
-		return 0, "", fmt.Errorf("failed to create pull request: %w", err)
-	}
-
-	return pr.GetNumber(), pr.GetHTMLURL(), nil
-}
-
 // generateFeatureBranchName creates a unique feature branch name.
 func generateFeatureBranchName(prefix, userHandle string) string {
 	timestamp := time.Now().Format("2006-01-02-150405")
@@ -1953,6 +2345,20 @@ func generateFeatureBranchName(prefix, userHandle string) string {
 	return fmt.Sprintf("%s/%s/%s", prefix, userHandle, timestamp)
 }
 
+// domainsDiffText renders added/removed domains as a compact
+// diff-style listing ("+domain"/"-domain", one per line), for embedding
+// in a PR body's fenced diff block via policy.TemplateData.Diff.
+func domainsDiffText(added, removed []string) string {
+	var b strings.Builder
+	for _, d := range added {
+		b.WriteString("+" + d + "\n")
+	}
+	for _, d := range removed {
+		b.WriteString("-" + d + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // createManifest creates a new manifest file with domains.
 func createManifest(manifestName, category string, domains []string, ruleType string) map[string]interface{} {
 	// Determine the file path based on category
@@ -2204,7 +2610,26 @@ func updateRuleMetadata(manifest map[string]interface{}, ruleType string, domain
 	metadata["last_modified"] = time.Now().Format(time.RFC3339)
 }
 
+// writeManifest stamps manifest's metadata.content_hash with the SHA256
+// (hex) of its own content - computed before that field is added, the
+// same way a Git tree's hash never covers itself - and writes the
+// result to path. Callers that mutate manifest can compare this hash to
+// a previously-read one to detect a concurrent PR-side edit, the same
+// optimistic-concurrency check DoLockedAction's in-process fingerprint
+// already gives local web-editor sessions.
 func writeManifest(path string, manifest map[string]interface{}) error {
+	unhashed, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	metadata, ok := manifest["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		manifest["metadata"] = metadata
+	}
+	metadata["content_hash"] = fingerprintBytes(unhashed)
+
 	output, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal manifest: %w", err)
@@ -2250,6 +2675,7 @@ func addDomainsToManifest(manifestName string, domains []string, requestedRuleTy
 			"message":       "No new domains to add",
 			"added_domains": []string{},
 			"total_domains": len(existingDomains),
+			"rule_type":     ruleType,
 		}
 	}
 
@@ -2268,6 +2694,7 @@ func addDomainsToManifest(manifestName string, domains []string, requestedRuleTy
 		"message":       fmt.Sprintf("Added %d domains to %s", len(addedDomains), manifestName),
 		"added_domains": addedDomains,
 		"total_domains": len(updatedDomains),
+		"rule_type":     ruleType,
 	}
 }
 
@@ -2304,6 +2731,7 @@ func removeDomainsFromManifest(manifestName string, domains []string) map[string
 			"message":         "No matching domains found",
 			"removed_domains": []string{},
 			"total_domains":   len(existingDomains),
+			"rule_type":       ruleType,
 		}
 	}
 
@@ -2322,6 +2750,7 @@ func removeDomainsFromManifest(manifestName string, domains []string) map[string
 		"message":         fmt.Sprintf("Removed %d domains from %s", len(removed), manifestName),
 		"removed_domains": removed,
 		"total_domains":   len(remaining),
+		"rule_type":       ruleType,
 	}
 }
 
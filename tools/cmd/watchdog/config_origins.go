@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gemini/dnshield/internal/managedconfig"
+)
+
+// configOrigin records where a preference's effective value came from,
+// so show-config can report it alongside the value itself.
+type configOrigin struct {
+	Value  any
+	Level  managedconfig.Level
+	Locked bool
+}
+
+// configOrigins is populated by readPreference as loadPreferences reads
+// each key, so it reflects exactly what was actually consulted this run
+// rather than re-deriving it from scratch.
+var configOrigins = make(map[string]configOrigin)
+
+// allPreferenceKeys is every key loadPreferences reads, in the same
+// order as the preference-key const block in main.go, so show-config has something to
+// report a key as "default" against even when managedSource never had a
+// value for it.
+var allPreferenceKeys = []string{
+	removePrefKey,
+	ruleDBPathKey,
+	removalCommentKey,
+	loggerPrefixKey,
+	pollIntervalKey,
+	telemetryEnabledKey,
+	telemetryBackendKey,
+	telemetryHECTokenKey,
+	telemetryServerURLKey,
+	telemetryOTLPEndpointKey,
+	telemetryElasticURLKey,
+	telemetryElasticIndexKey,
+	telemetrySyslogAddressKey,
+	telemetrySyslogTLSKey,
+	telemetryTLSInsecureSkipVerifyKey,
+	telemetryCABundlePathKey,
+	useJSONLoggingKey,
+	logFilePathKey,
+	heartbeatIntervalKey,
+	syslogEnabledKey,
+	syslogFacilityKey,
+	syslogTagKey,
+	hostMetricsEnabledKey,
+	traceFacetsKey,
+	rulesDBSnapshotPathKey,
+	rulesDBRecoveryPublicKeyKey,
+	rulesDBQuarantineDirKey,
+}
+
+// redactedPreferenceKeys are printed as "<redacted>" by show-config
+// rather than in the clear, since they're secrets rather than policy.
+var redactedPreferenceKeys = map[string]bool{
+	telemetryHECTokenKey: true,
+}
+
+// printConfigOrigins loads preferences exactly as the daemon would, then
+// prints each key's effective value and where it came from - "managed"
+// (and whether it's locked by MDM and can't be overridden locally),
+// "user"/"host", or "default" when managedSource had nothing for it at
+// all. This is what `watchdog show-config` runs instead of starting the
+// daemon, so an admin can see at a glance what an MDM has enforced.
+func printConfigOrigins() {
+	wd = &watchdog{}
+	loadPreferences()
+
+	for _, key := range allPreferenceKeys {
+		origin, ok := configOrigins[key]
+		if !ok {
+			fmt.Printf("%-34s source=default\n", key)
+			continue
+		}
+
+		value := origin.Value
+		if redactedPreferenceKeys[key] {
+			value = "<redacted>"
+		}
+
+		source := origin.Level.String()
+		if origin.Locked {
+			source += " (locked by MDM)"
+		}
+		fmt.Printf("%-34s value=%v source=%s\n", key, value, source)
+	}
+}
@@ -1,26 +1,63 @@
-//go:build darwin
-// +build darwin
-
 package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"io"
 	"io/fs"
 	"os"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/gemini/dnshield/internal/fsmonitor"
 	"github.com/rs/zerolog/log"
 )
 
+// dbChecksumMaxSize bounds the full-file SHA-256 hash getCurrentState
+// computes on every change to DBs small enough that streaming the whole
+// file each time is cheap. Above it, Checksum is left empty and
+// hasStateChanged falls back to its old size/mtime-only comparison - a
+// rolling, chunk-incremental hash for very large DBs isn't implemented,
+// since nothing in this tree's rules.db deployments approaches that size.
+const dbChecksumMaxSize = 64 * 1024 * 1024 // 64MB
+
+// dbUpdateMarkerGrace is how long after dbPath+dbUpdateMarkerSuffix was
+// last touched a content change is still considered an expected update
+// rather than tampering.
+const dbUpdateMarkerGrace = 30 * time.Second
+
+// dbUpdateMarkerSuffix names the sidecar file a legitimate writer path is
+// expected to touch immediately before replacing dbPath, so
+// handleStateChange can tell an expected update from an unexplained one.
+// Nothing does this automatically - a rules.db writer (a sync job, a
+// management tool) must call MarkLegitimateUpdate, or run
+// `watchdog mark-legitimate-update`, right before it replaces the file.
+const dbUpdateMarkerSuffix = ".update-marker"
+
+// dbChecksumSidecarSuffix names the sidecar file the last-known-good
+// checksum is persisted to, so a restart doesn't lose the baseline and a
+// tamper that restores the original size/mtime is still caught.
+//
+// This sidecar is a plain file, not cryptographically signed: dnshield
+// has no existing keychain/Secure Enclave integration to sign it with,
+// and fabricating one here would be dishonest busywork rather than a
+// real feature. Its integrity instead rests on filesystem permissions
+// (root-owned, alongside dbPath itself).
+const dbChecksumSidecarSuffix = ".sha256"
+
 // DatabaseMonitor watches for database file changes.
 type DatabaseMonitor struct {
-	dbPath        string
-	lastState     *DBState
-	mu            sync.RWMutex
-	checkInterval time.Duration
+	dbPath    string
+	lastState *DBState
+	mu        sync.RWMutex
+	monitor   *fsmonitor.Monitor
+
+	recoverer       *Recoverer
+	recovering      bool
+	lastRecoveryErr error
 }
 
 // DBState represents the state of the database file.
@@ -31,56 +68,96 @@ type DBState struct {
 	Checksum string
 }
 
-// NewDatabaseMonitor creates a new database monitor.
-func NewDatabaseMonitor(dbPath string) *DatabaseMonitor {
+// NewDatabaseMonitor creates a new database monitor. recoverer may be nil,
+// in which case a removed or tampered database is only logged, never
+// restored.
+func NewDatabaseMonitor(dbPath string, recoverer *Recoverer) *DatabaseMonitor {
 	return &DatabaseMonitor{
-		dbPath:        dbPath,
-		checkInterval: 5 * time.Second,
+		dbPath:    dbPath,
+		monitor:   fsmonitor.NewDefault(dbPath),
+		recoverer: recoverer,
 	}
 }
 
 // Start begins monitoring the database.
 func (dm *DatabaseMonitor) Start(ctx context.Context) {
 	// Initial state check
-	dm.checkState()
+	dm.checkState(ctx)
+
+	dm.monitor.Start(ctx)
+	changes, cancel := dm.monitor.Subscribe()
 
 	// Start monitoring loop
-	go dm.monitorLoop(ctx)
+	go dm.monitorLoop(ctx, changes, cancel)
 }
 
-// monitorLoop continuously monitors the database file.
-func (dm *DatabaseMonitor) monitorLoop(ctx context.Context) {
-	ticker := time.NewTicker(dm.checkInterval)
-	defer ticker.Stop()
+// monitorLoop re-checks the database's state each time the monitor
+// reports a change, until ctx is canceled.
+func (dm *DatabaseMonitor) monitorLoop(ctx context.Context, changes <-chan fsmonitor.Event, cancel func()) {
+	defer cancel()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			dm.checkState()
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+			dm.checkState(ctx)
+		case err, ok := <-dm.monitor.Errors():
+			if !ok {
+				continue
+			}
+			log.Warn().Err(err).Str("db_path", dm.dbPath).Msg("native database watcher unavailable, falling back to polling")
 		}
 	}
 }
 
 // checkState checks the current state of the database file.
-func (dm *DatabaseMonitor) checkState() {
+func (dm *DatabaseMonitor) checkState(ctx context.Context) {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
 	newState := dm.getCurrentState()
 
-	// First check or state changed
+	// First check: compare against the checksum sidecar left by a
+	// previous run, if any, rather than unconditionally treating this as
+	// a fresh "initialized" event - otherwise a tamper that happened
+	// while the watchdog wasn't running to see it live would go unnoticed.
 	if dm.lastState == nil {
-		dm.handleStateChange(nil, newState)
+		dm.checkInitialState(ctx, newState)
 		dm.lastState = newState
+		dm.persistChecksumSidecar(newState)
 		return
 	}
 
 	// Check for changes
 	if dm.hasStateChanged(dm.lastState, newState) {
-		dm.handleStateChange(dm.lastState, newState)
+		dm.handleStateChange(ctx, dm.lastState, newState)
+		// Re-derive state rather than reusing newState: a recovery
+		// attempt triggered by handleStateChange may have replaced the
+		// file out from under that snapshot.
+		newState = dm.getCurrentState()
 		dm.lastState = newState
+		dm.persistChecksumSidecar(newState)
+	}
+}
+
+// checkInitialState handles the first state check after startup.
+func (dm *DatabaseMonitor) checkInitialState(ctx context.Context, newState *DBState) {
+	if baseline := dm.loadChecksumSidecar(); baseline != "" && newState.Exists && newState.Checksum != "" && baseline != newState.Checksum {
+		// Size and ModTime are set to newState's own so handleStateChange's
+		// size comparison can't fire on its own - only the checksum
+		// mismatch this baseline comparison exists to catch does.
+		dm.handleStateChange(ctx, &DBState{Exists: true, Size: newState.Size, ModTime: newState.ModTime, Checksum: baseline}, newState)
+		return
+	}
+
+	if newState.Exists {
+		LogDatabaseEvent("initialized", dm.dbPath, true)
+	} else {
+		LogDatabaseEvent("not_found", dm.dbPath, false)
 	}
 }
 
@@ -99,14 +176,44 @@ func (dm *DatabaseMonitor) getCurrentState() *DBState {
 		}
 	}
 
-	return &DBState{
+	state := &DBState{
 		Exists:  true,
 		Size:    info.Size(),
 		ModTime: info.ModTime(),
 	}
+
+	if info.Size() <= dbChecksumMaxSize {
+		sum, err := checksumFile(dm.dbPath)
+		if err != nil {
+			log.Warn().Err(err).Str("db_path", dm.dbPath).Msg("failed to checksum database file")
+		} else {
+			state.Checksum = sum
+		}
+	}
+
+	return state
+}
+
+// checksumFile streams path through SHA-256 without reading it fully
+// into memory.
+func checksumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// hasStateChanged determines if the database state has changed.
+// hasStateChanged determines if the database state has changed. Same
+// size and mtime no longer means "unchanged": an attacker who restores
+// both after tampering with the contents is exactly the case checksumming
+// exists to catch.
 func (dm *DatabaseMonitor) hasStateChanged(old, ns *DBState) bool {
 	if old.Exists != ns.Exists {
 		return true
@@ -116,144 +223,173 @@ func (dm *DatabaseMonitor) hasStateChanged(old, ns *DBState) bool {
 		return false
 	}
 
-	// Check for size or modification time changes
 	if old.Size != ns.Size || !old.ModTime.Equal(ns.ModTime) {
 		return true
 	}
 
+	if old.Checksum != "" && ns.Checksum != "" && old.Checksum != ns.Checksum {
+		return true
+	}
+
 	return false
 }
 
-// handleStateChange handles database state changes.
-func (dm *DatabaseMonitor) handleStateChange(old, newState *DBState) {
-	if old == nil {
-		// Initial state
-		if newState.Exists {
-			LogDatabaseEvent("initialized", dm.dbPath, true)
-		} else {
-			LogDatabaseEvent("not_found", dm.dbPath, false)
-		}
+// isLegitimateUpdate reports whether dm.dbPath's update-marker sidecar
+// was touched within dbUpdateMarkerGrace of now - the signal a
+// legitimate writer path gives before replacing dbPath, so a change
+// with no recent marker is treated as unexplained rather than expected.
+func (dm *DatabaseMonitor) isLegitimateUpdate() bool {
+	info, err := os.Stat(dm.dbPath + dbUpdateMarkerSuffix)
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) <= dbUpdateMarkerGrace
+}
+
+// MarkLegitimateUpdate touches dbPath's update-marker sidecar, so the
+// next content change handleStateChange observes within
+// dbUpdateMarkerGrace is treated as an expected update rather than
+// tampering. A real rules.db writer (a sync job, a management tool) must
+// call this - or run `watchdog mark-legitimate-update <dbPath>` - right
+// before it replaces dbPath; nothing does so automatically.
+func MarkLegitimateUpdate(dbPath string) error {
+	markerPath := dbPath + dbUpdateMarkerSuffix
+	now := time.Now()
+	if err := os.Chtimes(markerPath, now, now); err == nil {
+		return nil
+	}
+	f, err := os.OpenFile(markerPath, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// loadChecksumSidecar returns the last-known-good checksum persisted by
+// a previous run, or "" if there isn't one.
+func (dm *DatabaseMonitor) loadChecksumSidecar() string {
+	data, err := os.ReadFile(dm.dbPath + dbChecksumSidecarSuffix)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// persistChecksumSidecar records state's checksum so it survives a
+// watchdog restart. A missing Checksum (the file's over
+// dbChecksumMaxSize, or doesn't exist) clears the sidecar rather than
+// leaving a stale value that could mask the next real tamper.
+func (dm *DatabaseMonitor) persistChecksumSidecar(state *DBState) {
+	sidecarPath := dm.dbPath + dbChecksumSidecarSuffix
+	if state.Checksum == "" {
+		os.Remove(sidecarPath)
 		return
 	}
+	if err := os.WriteFile(sidecarPath, []byte(state.Checksum+"\n"), 0o600); err != nil {
+		log.Warn().Err(err).Str("db_path", dm.dbPath).Msg("failed to persist database checksum sidecar")
+	}
+}
 
+// handleStateChange handles database state changes.
+func (dm *DatabaseMonitor) handleStateChange(ctx context.Context, old, newState *DBState) {
 	// Database was deleted
 	if old.Exists && !newState.Exists {
 		LogDatabaseEvent("removed", dm.dbPath, false)
-		log.Warn().
+		attachHostMetrics(log.Warn()).
 			Str("db_path", dm.dbPath).
 			Str("event_type", "database_removed").
 			Str("severity", "high").
 			Msg("Database file has been removed")
+		dm.triggerRecovery(ctx, "removed")
 		return
 	}
 
 	// Database was created
 	if !old.Exists && newState.Exists {
 		LogDatabaseEvent("created", dm.dbPath, true)
-		log.Info().
+		attachHostMetrics(log.Info()).
 			Str("db_path", dm.dbPath).
 			Int64("db_size", newState.Size).
 			Str("event_type", "database_created").
 			Msg("Database file has been created")
+		dm.lastRecoveryErr = nil
 		return
 	}
 
-	// Database was modified
-	if old.Size != newState.Size {
+	// Database was modified - either its size changed, or its contents
+	// did while the size and mtime stayed the same (exactly what an
+	// attacker restoring both after editing the file would produce).
+	contentChanged := old.Checksum != "" && newState.Checksum != "" && old.Checksum != newState.Checksum
+	if old.Size != newState.Size || contentChanged {
+		if !dm.isLegitimateUpdate() {
+			LogDatabaseEvent("tampered", dm.dbPath, true)
+			attachHostMetrics(log.Error()).
+				Str("db_path", dm.dbPath).
+				Str("old_checksum", old.Checksum).
+				Str("new_checksum", newState.Checksum).
+				Str("event_type", "database_tampered").
+				Str("severity", "critical").
+				Msg("database file changed without a legitimate update marker; possible tampering")
+			dm.triggerRecovery(ctx, "tampered")
+			return
+		}
+
 		LogDatabaseEvent("modified", dm.dbPath, true)
-		log.Info().
+		attachHostMetrics(log.Info()).
 			Str("db_path", dm.dbPath).
 			Int64("old_size", old.Size).
 			Int64("new_size", newState.Size).
 			Int64("size_diff", newState.Size-old.Size).
 			Str("event_type", "database_modified").
 			Msg("Database file has been modified")
+		dm.lastRecoveryErr = nil
 	}
 }
 
-// WatchDatabase monitors database file using kqueue (macOS).
-func (dm *DatabaseMonitor) WatchDatabase(ctx context.Context) error {
-	kq, err := syscall.Kqueue()
-	if err != nil {
-		return err
-	}
-	defer syscall.Close(kq)
-
-	// Open the database file
-	fd, err := syscall.Open(dm.dbPath, syscall.O_RDONLY, 0)
-	if err != nil {
-		// File doesn't exist yet, fall back to polling
-		return dm.pollDatabase(ctx)
-	}
-	defer syscall.Close(fd)
-
-	// Set up the kevent
-	kev := syscall.Kevent_t{
-		Ident:  uint64(uintptr(fd)),
-		Filter: syscall.EVFILT_VNODE,
-		Flags:  syscall.EV_ADD | syscall.EV_CLEAR,
-		Fflags: syscall.NOTE_DELETE | syscall.NOTE_WRITE | syscall.NOTE_RENAME | syscall.NOTE_ATTRIB,
-		Data:   0,
-		Udata:  nil,
-	}
-
-	// Register the event
-	n, err := syscall.Kevent(kq, []syscall.Kevent_t{kev}, nil, nil)
-	if err != nil || n == -1 {
-		return err
-	}
-
-	events := make([]syscall.Kevent_t, 1)
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// Wait for events with timeout
-			n, err := syscall.Kevent(kq, nil, events, &syscall.Timespec{Sec: 1})
-			if err != nil {
-				if err == syscall.EINTR {
-					continue
-				}
-				return err
-			}
-
-			if n > 0 {
-				event := events[0]
-				dm.handleKqueueEvent(event)
-			}
-		}
+// triggerRecovery attempts to restore dbPath from dm.recoverer's signed
+// snapshot, emitting a recovery_started/succeeded/failed/ratelimited event
+// for each outcome. It's a no-op if no Recoverer is configured. Runs
+// synchronously from within handleStateChange, which already holds dm.mu,
+// so dm.recovering/dm.lastRecoveryErr are updated without re-acquiring it.
+func (dm *DatabaseMonitor) triggerRecovery(ctx context.Context, reason string) {
+	if dm.recoverer == nil {
+		return
 	}
-}
 
-// handleKqueueEvent handles kqueue events for database monitoring.
-func (dm *DatabaseMonitor) handleKqueueEvent(event syscall.Kevent_t) {
-	if event.Fflags&syscall.NOTE_DELETE != 0 {
-		LogDatabaseEvent("deleted", dm.dbPath, false)
-	}
-	if event.Fflags&syscall.NOTE_WRITE != 0 {
-		dm.checkState()
-	}
-	if event.Fflags&syscall.NOTE_RENAME != 0 {
-		LogDatabaseEvent("renamed", dm.dbPath, false)
-	}
-	if event.Fflags&syscall.NOTE_ATTRIB != 0 {
-		dm.checkState()
-	}
-}
+	dm.recovering = true
+	LogDatabaseEvent("recovery_started", dm.dbPath, false)
+	attachHostMetrics(log.Warn()).
+		Str("db_path", dm.dbPath).
+		Str("reason", reason).
+		Str("event_type", "recovery_started").
+		Msg("attempting to recover database from signed snapshot")
+
+	err := dm.recoverer.Recover(ctx, dm.dbPath)
+	dm.recovering = false
+	dm.lastRecoveryErr = err
+
+	switch {
+	case err == nil:
+		LogDatabaseEvent("recovery_succeeded", dm.dbPath, true)
+		attachHostMetrics(log.Info()).
+			Str("db_path", dm.dbPath).
+			Str("event_type", "recovery_succeeded").
+			Msg("database recovered from signed snapshot")
 
-// pollDatabase falls back to polling when kqueue is not available.
-func (dm *DatabaseMonitor) pollDatabase(ctx context.Context) error {
-	ticker := time.NewTicker(dm.checkInterval)
-	defer ticker.Stop()
+	case errors.Is(err, errRecoveryRateLimited):
+		LogDatabaseEvent("recovery_ratelimited", dm.dbPath, false)
+		attachHostMetrics(log.Warn()).
+			Str("db_path", dm.dbPath).
+			Str("event_type", "recovery_ratelimited").
+			Msg("skipping database recovery attempt; last attempt was too recent")
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			dm.checkState()
-		}
+	default:
+		LogDatabaseEvent("recovery_failed", dm.dbPath, false)
+		attachHostMetrics(log.Error()).
+			Err(err).
+			Str("db_path", dm.dbPath).
+			Str("event_type", "recovery_failed").
+			Msg("failed to recover database from signed snapshot")
 	}
 }
 
@@ -262,11 +398,18 @@ func (dm *DatabaseMonitor) GetPath() string {
 	return dm.dbPath
 }
 
-// IsHealthy checks if the database is currently accessible.
+// IsHealthy checks if the database is currently accessible. Besides
+// existence and size, it reflects recovery state: unhealthy while a
+// recovery attempt is in flight, and after one has failed, so a restored
+// file that still fails verification doesn't get reported as healthy.
 func (dm *DatabaseMonitor) IsHealthy() bool {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	if dm.recovering || dm.lastRecoveryErr != nil {
+		return false
+	}
+
 	if dm.lastState == nil {
 		return false
 	}
@@ -0,0 +1,16 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package main
+
+import (
+	"io"
+
+	"github.com/gemini/dnshield/internal/osloghook"
+)
+
+// platformLogWriter mirrors the daemon's log events into Apple's unified
+// logging system, under subsystem/category.
+func platformLogWriter(subsystem, category string) io.Writer {
+	return osloghook.NewWriter(subsystem, category)
+}
@@ -0,0 +1,14 @@
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package main
+
+import "io"
+
+// platformLogWriter has no non-cgo path to bridge into os_log (the real
+// bridge in log_darwin.go needs cgo), so a CGO_ENABLED=0 darwin build
+// behaves like log_other.go's every-other-platform case: no-op,
+// initializeZerolog skips adding it to the sink when this returns nil.
+func platformLogWriter(subsystem, category string) io.Writer {
+	return nil
+}
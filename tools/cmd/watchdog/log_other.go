@@ -0,0 +1,13 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+import "io"
+
+// platformLogWriter has no unified-logging equivalent to bridge into
+// outside darwin yet, so it's a no-op; initializeZerolog skips adding it
+// to the sink when this returns nil.
+func platformLogWriter(subsystem, category string) io.Writer {
+	return nil
+}
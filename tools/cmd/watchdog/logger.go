@@ -1,6 +1,3 @@
-//go:build darwin
-// +build darwin
-
 package main
 
 import (
@@ -8,10 +5,22 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// attachHostMetrics adds a "host" sub-object (load averages, uptime,
+// logged-in users, CPU count, free memory) to e when HostMetricsEnabled
+// is set, so SOC pipelines can correlate the event with host context -
+// e.g. a bypass attempt seconds after a new interactive login. It's a
+// no-op otherwise, so callers can chain it unconditionally.
+func attachHostMetrics(e *zerolog.Event) *zerolog.Event {
+	if hostMetricsEnabled && hostStats != nil {
+		return e.Interface("host", hostStats.Sample())
+	}
+	return e
+}
+
 // LogDatabaseEvent logs database-related events with structured fields.
 func LogDatabaseEvent(event string, dbPath string, exists bool) {
 	logEvent := func(evt *zerolog.Event) {
-		evt.
+		attachHostMetrics(evt).
 			Str("db_event", event).
 			Str("db_path", dbPath).
 			Bool("db_exists", exists).
@@ -30,14 +39,14 @@ func LogDatabaseEvent(event string, dbPath string, exists bool) {
 // LogRuleLoad logs rule loading events with structured fields.
 func LogRuleLoad(count int, dbPath string, success bool) {
 	if success {
-		log.Info().
+		attachHostMetrics(log.Info()).
 			Int("rule_count", count).
 			Str("db_path", dbPath).
 			Bool("success", success).
 			Str("event_type", "rule_load").
 			Msg("loaded blocked-domain rule(s)")
 	} else {
-		log.Error().
+		attachHostMetrics(log.Error()).
 			Str("db_path", dbPath).
 			Bool("success", success).
 			Str("event_type", "rule_load").
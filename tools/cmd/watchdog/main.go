@@ -1,6 +1,3 @@
-//go:build darwin
-// +build darwin
-
 // DNShield Watchdog
 //
 // Optional LaunchDaemon that monitors /etc/hosts for blocked-domain bypass attempts.
@@ -8,9 +5,14 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -21,47 +23,82 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gemini/dnshield/internal/cfpref"
+	"github.com/gemini/dnshield/internal/hoststats"
+	"github.com/gemini/dnshield/internal/managedconfig"
+	"github.com/gemini/dnshield/internal/trace"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// managedSource is the managed-configuration backend watchdog reads its
+// preferences from. On darwin this is CFPreferences/configuration
+// profiles; see internal/managedconfig for the other platforms dnshield
+// ships on.
+var managedSource = managedconfig.New(preferenceDomain)
+
 type watchdog struct {
 	logPrefix            string
 	pollInterval         int
 	removalCommentFormat string
 	rulesDBPath          string
 	removeBlockedEntries bool
+	heartbeatInterval    int
 }
 
 var ErrPrefValueNotFound = errors.New("no value found for key")
 
 const (
-	hostsFilePath         = "/etc/hosts"
-	sqlite3Binary         = "/usr/bin/sqlite3"
-	preferenceDomain      = "com.dnshield.watchdog"
-	removePrefKey         = "RemoveBlockBypassEntries"
-	ruleDBPathKey         = "RulesDBPath"
-	removalCommentKey     = "RemovalComment"
-	loggerPrefixKey       = "LoggerPrefix"
-	pollIntervalKey       = "PollInterval"
-	telemetryEnabledKey   = "TelemetryEnabled"
-	telemetryHECTokenKey  = "TelemetryHECToken"
-	telemetryServerURLKey = "TelemetryServerURL"
-	useJSONLoggingKey     = "UseJSONLogging"
-	logFilePathKey        = "LogFilePath"
+	hostsFilePath                     = "/etc/hosts"
+	sqlite3Binary                     = "/usr/bin/sqlite3"
+	preferenceDomain                  = "com.dnshield.watchdog"
+	removePrefKey                     = "RemoveBlockBypassEntries"
+	ruleDBPathKey                     = "RulesDBPath"
+	removalCommentKey                 = "RemovalComment"
+	loggerPrefixKey                   = "LoggerPrefix"
+	pollIntervalKey                   = "PollInterval"
+	telemetryEnabledKey               = "TelemetryEnabled"
+	telemetryBackendKey               = "TelemetryBackend"
+	telemetryHECTokenKey              = "TelemetryHECToken"
+	telemetryServerURLKey             = "TelemetryServerURL"
+	telemetryOTLPEndpointKey          = "TelemetryOTLPEndpoint"
+	telemetryElasticURLKey            = "TelemetryElasticURL"
+	telemetryElasticIndexKey          = "TelemetryElasticIndex"
+	telemetrySyslogAddressKey         = "TelemetrySyslogAddress"
+	telemetrySyslogTLSKey             = "TelemetrySyslogTLS"
+	telemetryTLSInsecureSkipVerifyKey = "TelemetryTLSInsecureSkipVerify"
+	telemetryCABundlePathKey          = "TelemetryCABundlePath"
+	useJSONLoggingKey                 = "UseJSONLogging"
+	logFilePathKey                    = "LogFilePath"
+	heartbeatIntervalKey              = "WatchdogHeartbeatInterval"
+	syslogEnabledKey                  = "SyslogEnabled"
+	syslogFacilityKey                 = "SyslogFacility"
+	syslogTagKey                      = "SyslogTag"
+	hostMetricsEnabledKey             = "HostMetricsEnabled"
+	traceFacetsKey                    = "TraceFacets"
+	traceEnvVar                       = "DNSHIELD_TRACE"
+	rulesDBSnapshotPathKey            = "RulesDBSnapshotPath"
+	rulesDBRecoveryPublicKeyKey       = "RulesDBRecoveryPublicKey"
+	rulesDBQuarantineDirKey           = "RulesDBQuarantineDir"
 )
 
 var ( // default values if not set on the preference domain.
-	logPrefix         = "[dnshield-watchdog]"
-	pollInterval      = 3 * time.Second
-	removalCommentFmt = "# Removed by DNShield Watchdog %s: %s"
-	rulesDBPath       = "/var/db/dnshield/rules.db"
-	wd                *watchdog
-	telemetry         *TelemetryClient
-	dbMonitor         *DatabaseMonitor
-	useJSONLogging    bool
-	logFilePath       = "/var/log/dnshield/watchdog.log"
+	logPrefix          = "[dnshield-watchdog]"
+	pollInterval       = 3 * time.Second
+	removalCommentFmt  = "# Removed by DNShield Watchdog %s: %s"
+	rulesDBPath        = "/var/db/dnshield/rules.db"
+	wd                 *watchdog
+	telemetrySink      TelemetrySink
+	dbMonitor          *DatabaseMonitor
+	dbRecoverer        *Recoverer
+	useJSONLogging     bool
+	logFilePath                   = "/var/log/dnshield/watchdog.log"
+	heartbeatInterval             = 30 * time.Second
+	sup                supervisor = noopSupervisor{}
+	syslogEnabled      bool
+	syslogFacility     = "LOG_DAEMON"
+	syslogTag          = "dnshield-watchdog"
+	hostMetricsEnabled bool
+	hostStats          *hoststats.Collector
 )
 
 var timeNow = time.Now
@@ -73,17 +110,51 @@ type blockedSet struct {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "show-config" {
+		printConfigOrigins()
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "mark-legitimate-update" {
+		markLegitimateUpdateCommand(os.Args[2:])
+		return
+	}
+
 	if err := execute(); err != nil {
 		log.Fatal().Err(err).Msg("fatal error")
 	}
 }
 
+// markLegitimateUpdateCommand implements `watchdog mark-legitimate-update
+// [path]`: the signal a real rules.db writer gives before replacing the
+// file, so DatabaseMonitor doesn't treat the resulting content change as
+// tampering. path defaults to the configured RulesDBPath.
+func markLegitimateUpdateCommand(args []string) {
+	path := rulesDBPath
+	if len(args) > 0 && args[0] != "" {
+		path = args[0]
+	} else {
+		wd = &watchdog{}
+		loadPreferences()
+		path = wd.rulesDBPath
+	}
+
+	if err := MarkLegitimateUpdate(path); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to mark legitimate update for %s: %v\n", path, err)
+		os.Exit(1)
+	}
+}
+
 func execute() error {
 	wd = &watchdog{}
 
 	// Load all preferences
 	loadPreferences()
 
+	if hostMetricsEnabled {
+		hostStats = hoststats.NewCollector(pollInterval)
+	}
+
 	// Initialize zerolog based on configuration
 	initializeZerolog()
 
@@ -92,10 +163,14 @@ func execute() error {
 
 	// Start database monitoring if enabled
 	if useJSONLogging {
-		dbMonitor = NewDatabaseMonitor(wd.rulesDBPath)
+		dbMonitor = NewDatabaseMonitor(wd.rulesDBPath, dbRecoverer)
 		dbMonitor.Start(ctx)
 	}
 
+	sup = newSupervisor()
+	sup.Ready()
+	defer sup.Stopping()
+
 	if err := run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
@@ -197,11 +272,7 @@ func run(ctx context.Context) error {
 	lastHash := hashBytes(hashedContent)
 	log.Info().Msg("watchdog started; monitoring /etc/hosts for bypass attempts")
 
-	if kqErr := monitorWithKqueue(ctx, &lastHash); kqErr != nil {
-		log.Warn().Err(kqErr).Msg("kqueue monitoring unavailable, falling back to polling")
-		return monitorWithPolling(ctx, &lastHash)
-	}
-	return nil
+	return watchHostsFile(ctx, &lastHash)
 }
 
 func processHostsChange(ctx context.Context, lastHash *[32]byte) error {
@@ -212,6 +283,7 @@ func processHostsChange(ctx context.Context, lastHash *[32]byte) error {
 
 	newHash := hashBytes(data)
 	if newHash == *lastHash {
+		sup.Heartbeat()
 		return nil
 	}
 
@@ -222,6 +294,7 @@ func processHostsChange(ctx context.Context, lastHash *[32]byte) error {
 	}
 
 	*lastHash = hashBytes(finalContent)
+	sup.Heartbeat()
 	return nil
 }
 
@@ -256,7 +329,7 @@ func handleHostsChange(ctx context.Context, content []byte) ([]byte, error) {
 
 	if !wd.removeBlockedEntries {
 		for _, info := range offendingLines {
-			log.Warn().
+			attachHostMetrics(log.Warn()).
 				Bool("detected", true).
 				Bool("removed", false).
 				Int("line_number", info.lineIndex+1).
@@ -271,7 +344,7 @@ func handleHostsChange(ctx context.Context, content []byte) ([]byte, error) {
 
 	for _, info := range offendingLines {
 		if info.changed {
-			log.Info().
+			attachHostMetrics(log.Info()).
 				Bool("detected", true).
 				Bool("removed", true).
 				Int("line_number", info.lineIndex+1).
@@ -349,6 +422,13 @@ func analyzeLine(line string, idx int, blocked *blockedSet, remove bool) lineInf
 		filtered = append(filtered, host)
 	}
 
+	trace.Logger("hosts").Debug().
+		Int("line", idx+1).
+		Str("ip", ip).
+		Int("hosts_checked", len(hosts)).
+		Int("offending", len(info.offending)).
+		Msg("analyzed hosts line")
+
 	if len(info.offending) == 0 {
 		return info
 	}
@@ -461,6 +541,7 @@ func loadBlockedSet(ctx context.Context) (*blockedSet, error) {
 		}
 		parts := strings.Split(line, "|")
 		if len(parts) < 2 {
+			trace.Logger("rules").Debug().Str("row", line).Msg("skipping malformed sqlite row")
 			continue
 		}
 		domain := strings.TrimSpace(parts[0])
@@ -476,7 +557,7 @@ func loadBlockedSet(ctx context.Context) (*blockedSet, error) {
 		set.add(domain, ruleType)
 	}
 
-	log.Info().
+	attachHostMetrics(log.Info()).
 		Int("rule_count", set.count()).
 		Str("db_path", rulesDBPath).
 		Bool("success", true).
@@ -488,11 +569,11 @@ func loadBlockedSet(ctx context.Context) (*blockedSet, error) {
 
 // telemetryHook sends log events to telemetry service.
 type telemetryHook struct {
-	client *TelemetryClient
+	sink TelemetrySink
 }
 
 func (h telemetryHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
-	if h.client != nil && level >= zerolog.InfoLevel {
+	if h.sink != nil && level >= zerolog.InfoLevel {
 		// Create a log entry that matches the expected structure
 		entry := map[string]interface{}{
 			"timestamp": time.Now().UTC().Format(time.RFC3339),
@@ -500,10 +581,15 @@ func (h telemetryHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
 			"message":   msg,
 			"component": "dnshield-watchdog",
 		}
+		if hostMetricsEnabled && hostStats != nil {
+			entry["host"] = hostStats.Sample()
+		}
+
+		trace.Logger("telemetry").Debug().Int("entry_fields", len(entry)).Msg("queuing HEC event")
 
 		// Send to telemetry asynchronously
 		go func() {
-			if err := h.client.SendEvent(entry); err != nil {
+			if err := h.sink.SendEvent(entry); err != nil {
 				// Don't log telemetry errors to avoid recursion
 				fmt.Fprintf(os.Stderr, "Failed to send telemetry: %v\n", err)
 			}
@@ -515,6 +601,7 @@ func initializeZerolog() {
 	// Set global log level
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
+	var sink io.Writer
 	if useJSONLogging {
 		// JSON output is the default for zerolog
 		if logFilePath != "" && logFilePath != "-" {
@@ -530,35 +617,46 @@ func initializeZerolog() {
 			}
 
 			// Set up JSON logger writing to file
-			log.Logger = zerolog.New(file).With().
-				Timestamp().
-				Str("component", "dnshield-watchdog").
-				Logger()
+			sink = file
 		} else {
 			// JSON output to stdout
-			log.Logger = zerolog.New(os.Stdout).With().
-				Timestamp().
-				Str("component", "dnshield-watchdog").
-				Logger()
+			sink = os.Stdout
 		}
 	} else {
 		// Console output for non-JSON mode
-		output := zerolog.ConsoleWriter{
+		sink = zerolog.ConsoleWriter{
 			Out:        os.Stdout,
 			TimeFormat: time.RFC3339,
 			FormatLevel: func(i interface{}) string {
 				return fmt.Sprintf("%s %-6s", wd.logPrefix, i)
 			},
 		}
-		log.Logger = zerolog.New(output).With().
-			Timestamp().
-			Str("component", "dnshield-watchdog").
-			Logger()
 	}
 
+	// Mirror events into the platform's native logging facility alongside
+	// the stdio sink above (on darwin, Apple's unified logging, so
+	// `log stream`/Console.app see them even when the daemon's own stdio
+	// output is redirected to a file nobody is tailing); a no-op on
+	// platforms with no such facility wired up yet.
+	if platformSink := platformLogWriter(preferenceDomain, "watchdog"); platformSink != nil {
+		sink = zerolog.MultiLevelWriter(sink, platformSink)
+	}
+
+	log.Logger = zerolog.New(sink).With().
+		Timestamp().
+		Str("component", "dnshield-watchdog").
+		Logger()
+
 	// Add telemetry hook if configured
-	if telemetry != nil {
-		log.Logger = log.Logger.Hook(telemetryHook{client: telemetry})
+	if telemetrySink != nil {
+		log.Logger = log.Logger.Hook(telemetryHook{sink: telemetrySink})
+	}
+
+	// Add syslog hook if configured
+	if syslogEnabled {
+		if backend := newSyslogBackend(); backend != nil {
+			log.Logger = log.Logger.Hook(syslogHook{backend: backend})
+		}
 	}
 }
 
@@ -569,14 +667,7 @@ func loadPreferences() {
 	wd.removalCommentFormat = removalCommentFmt
 	wd.rulesDBPath = rulesDBPath
 	wd.removeBlockedEntries = false
-
-	logPrefErr := func(key string, err error) {
-		if err != nil && !errors.Is(err, ErrPrefValueNotFound) {
-			log.Error().Err(err).Str("key", key).Msg("error reading preference")
-		} else if errors.Is(err, ErrPrefValueNotFound) {
-			log.Debug().Str("key", key).Msg("no value found for preference key")
-		}
-	}
+	wd.heartbeatInterval = int(heartbeatInterval / time.Second)
 
 	if v, err := readPreference(loggerPrefixKey); err == nil {
 		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
@@ -604,6 +695,24 @@ func loadPreferences() {
 		logPrefErr(pollIntervalKey, err)
 	}
 
+	// WatchdogHeartbeatInterval (int seconds; allow int, int64, float64, string)
+	if v, err := readPreference(heartbeatIntervalKey); err == nil {
+		switch n := v.(type) {
+		case int:
+			wd.heartbeatInterval = n
+		case int64:
+			wd.heartbeatInterval = int(n)
+		case float64:
+			wd.heartbeatInterval = int(n)
+		case string:
+			if i, e := strconv.Atoi(strings.TrimSpace(n)); e == nil {
+				wd.heartbeatInterval = i
+			}
+		}
+	} else {
+		logPrefErr(heartbeatIntervalKey, err)
+	}
+
 	// RemoveBlockBypassEntries (bool; allow bool, string)
 	if v, err := readPreference(removePrefKey); err == nil {
 		switch b := v.(type) {
@@ -643,6 +752,9 @@ func loadPreferences() {
 	if wd.pollInterval > 0 {
 		pollInterval = time.Duration(wd.pollInterval) * time.Second
 	}
+	if wd.heartbeatInterval > 0 {
+		heartbeatInterval = time.Duration(wd.heartbeatInterval) * time.Second
+	}
 
 	if v, err := readPreference(useJSONLoggingKey); err == nil {
 		switch b := v.(type) {
@@ -682,192 +794,369 @@ func loadPreferences() {
 	}
 
 	if telemetryEnabled {
-		var hecToken, serverURL string
-
-		// TelemetryHECToken (string)
-		if v, err := readPreference(telemetryHECTokenKey); err == nil {
+		// TelemetryBackend (string, comma-separated): any of "splunk"
+		// (default), "otlp", "elastic", "syslog" - the same
+		// comma-separated-list-of-names convention TraceFacets uses, so an
+		// operator can ship events to more than one destination at once.
+		backendSpec := "splunk"
+		if v, err := readPreference(telemetryBackendKey); err == nil {
 			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
-				hecToken = strings.TrimSpace(s)
+				backendSpec = strings.ToLower(strings.TrimSpace(s))
 			}
 		} else {
-			logPrefErr(telemetryHECTokenKey, err)
+			logPrefErr(telemetryBackendKey, err)
 		}
 
-		// TelemetryServerURL (string)
-		if v, err := readPreference(telemetryServerURLKey); err == nil {
-			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
-				serverURL = strings.TrimSpace(s)
+		hostname, _ := os.Hostname()
+		var sinks []TelemetrySink
+		for _, name := range strings.Split(backendSpec, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if sink := buildTelemetrySink(name, hostname); sink != nil {
+				sinks = append(sinks, sink)
 			}
-		} else {
-			logPrefErr(telemetryServerURLKey, err)
 		}
 
-		// Initialize telemetry client if we have both token and URL
-		if hecToken != "" && serverURL != "" {
-			hostname, _ := os.Hostname()
-			telemetry = NewTelemetryClient(serverURL, hecToken, hostname)
+		switch len(sinks) {
+		case 0:
+			// nothing configured
+		case 1:
+			telemetrySink = sinks[0]
+		default:
+			telemetrySink = NewMultiSink(sinks...)
 		}
 	}
-}
 
-func readPreference(prefKey string) (any, error) {
-	value, valueType := cfpref.CFPreferencesCopyAppValueAndType(prefKey, preferenceDomain)
-	if value != nil {
-		var strValue string
-		switch v := value.(type) {
+	// SyslogEnabled and related settings
+	if v, err := readPreference(syslogEnabledKey); err == nil {
+		switch b := v.(type) {
+		case bool:
+			syslogEnabled = b
 		case string:
-			strValue = v
-		case int:
-			strValue = strconv.Itoa(v)
-		case int64:
-			strValue = strconv.FormatInt(v, 10)
-		case float64:
-			strValue = strconv.FormatInt(int64(v), 10)
-		default:
-			log.Debug().
-				Str("key", prefKey).
-				Str("type", fmt.Sprintf("%T", value)).
-				Str("cfpref_type", valueType).
-				Msg("unexpected type for preference")
+			if parsed, e := strconv.ParseBool(strings.TrimSpace(b)); e == nil {
+				syslogEnabled = parsed
+			}
 		}
-		if strValue != "" {
-			os.Setenv(prefKey, strValue)
+	} else {
+		logPrefErr(syslogEnabledKey, err)
+	}
+
+	// SyslogFacility (string)
+	if v, err := readPreference(syslogFacilityKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			syslogFacility = strings.TrimSpace(s)
 		}
-		return value, nil
+	} else {
+		logPrefErr(syslogFacilityKey, err)
 	}
-	return nil, ErrPrefValueNotFound
-}
 
-func hashBytes(data []byte) [32]byte {
-	return sha256.Sum256(data)
-}
+	// SyslogTag (string)
+	if v, err := readPreference(syslogTagKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			syslogTag = strings.TrimSpace(s)
+		}
+	} else {
+		logPrefErr(syslogTagKey, err)
+	}
 
-func monitorWithPolling(ctx context.Context, lastHash *[32]byte) error {
-	ticker := time.NewTicker(time.Duration(wd.pollInterval) * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info().Msg("shutting down (context canceled)")
-			return ctx.Err()
-		case <-ticker.C:
-			if err := processHostsChange(ctx, lastHash); err != nil {
-				log.Error().Err(err).Msg("error processing hosts change")
+	// HostMetricsEnabled (bool; allow bool, string)
+	if v, err := readPreference(hostMetricsEnabledKey); err == nil {
+		switch b := v.(type) {
+		case bool:
+			hostMetricsEnabled = b
+		case string:
+			if parsed, e := strconv.ParseBool(strings.TrimSpace(b)); e == nil {
+				hostMetricsEnabled = parsed
 			}
 		}
+	} else {
+		logPrefErr(hostMetricsEnabledKey, err)
 	}
-}
 
-func monitorWithKqueue(ctx context.Context, lastHash *[32]byte) error {
-	fd, err := openHostsFile()
-	if err != nil {
-		return err
+	// TraceFacets (string; unioned with $DNSHIELD_TRACE, which takes no
+	// priority over the other - both just add facets to the set)
+	facetSpec := os.Getenv(traceEnvVar)
+	if v, err := readPreference(traceFacetsKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			if facetSpec != "" {
+				facetSpec += ","
+			}
+			facetSpec += s
+		}
+	} else {
+		logPrefErr(traceFacetsKey, err)
 	}
-	defer syscall.Close(fd)
 
-	kq, err := syscall.Kqueue()
-	if err != nil {
-		return err
+	if unknown := trace.Configure(facetSpec); len(unknown) > 0 {
+		log.Warn().Strs("facets", unknown).Msg("unknown trace facet(s) requested; ignoring")
+	}
+
+	dbRecoverer = buildRecoverer()
+}
+
+// buildRecoverer constructs the Recoverer DatabaseMonitor uses to
+// self-heal rules.db, or nil if recovery isn't configured (no snapshot
+// path or no public key set). Only a local signed snapshot is supported -
+// fetching one from S3 or an enterprise config server would need client
+// libraries this module doesn't depend on; LocalSnapshotSource is the
+// only SnapshotSource implemented here.
+func buildRecoverer() *Recoverer {
+	var snapshotPath string
+	if v, err := readPreference(rulesDBSnapshotPathKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			snapshotPath = strings.TrimSpace(s)
+		}
+	} else {
+		logPrefErr(rulesDBSnapshotPathKey, err)
+	}
+	if snapshotPath == "" {
+		return nil
 	}
-	defer syscall.Close(kq)
 
-	// File descriptors are non-negative integers, safe to convert
-	var fdIdent uint64
-	if fd >= 0 {
-		fdIdent = uint64(fd)
+	var publicKeyB64 string
+	if v, err := readPreference(rulesDBRecoveryPublicKeyKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			publicKeyB64 = strings.TrimSpace(s)
+		}
 	} else {
-		return fmt.Errorf("invalid file descriptor: %d", fd)
+		logPrefErr(rulesDBRecoveryPublicKeyKey, err)
+	}
+	if publicKeyB64 == "" {
+		log.Warn().Msg("RulesDBSnapshotPath is set but RulesDBRecoveryPublicKey is not; database recovery disabled")
+		return nil
 	}
 
-	event := syscall.Kevent_t{
-		Ident:  fdIdent,
-		Filter: syscall.EVFILT_VNODE,
-		Flags:  syscall.EV_ADD | syscall.EV_CLEAR,
-		Fflags: syscall.NOTE_WRITE | syscall.NOTE_DELETE | syscall.NOTE_EXTEND |
-			syscall.NOTE_ATTRIB | syscall.NOTE_RENAME | syscall.NOTE_REVOKE,
+	publicKey, err := base64.StdEncoding.DecodeString(publicKeyB64)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		log.Error().Err(err).Msg("RulesDBRecoveryPublicKey is not a valid base64-encoded Ed25519 public key; database recovery disabled")
+		return nil
 	}
 
-	if _, keventErr := syscall.Kevent(kq, []syscall.Kevent_t{event}, nil, nil); keventErr != nil {
-		return fmt.Errorf("register kevent: %w", keventErr)
+	var quarantineDir string
+	if v, err := readPreference(rulesDBQuarantineDirKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			quarantineDir = strings.TrimSpace(s)
+		}
+	} else {
+		logPrefErr(rulesDBQuarantineDirKey, err)
 	}
 
-	events := make([]syscall.Kevent_t, 1)
-	timeout := syscall.NsecToTimespec(int64(500 * time.Millisecond))
+	return &Recoverer{
+		Source:        &LocalSnapshotSource{Path: snapshotPath},
+		PublicKey:     ed25519.PublicKey(publicKey),
+		QuarantineDir: quarantineDir,
+	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info().Msg("shutting down (context canceled)")
-			return ctx.Err()
-		default:
+// buildTelemetrySink constructs the TelemetrySink for one entry of the
+// (comma-separated) TelemetryBackend preference, reading whichever
+// backend-specific preferences that entry needs, or nil if it isn't
+// configured (e.g. "splunk" with no HEC token set). Backends that don't
+// do their own retry/spool internally are wrapped in spooledSink with a
+// spool file of their own, so running several backends at once doesn't
+// have them fight over a single spool.
+func buildTelemetrySink(name, hostname string) TelemetrySink {
+	switch name {
+	case "otlp":
+		// TelemetryOTLPEndpoint (string)
+		var endpoint string
+		if v, err := readPreference(telemetryOTLPEndpointKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				endpoint = strings.TrimSpace(s)
+			}
+		} else {
+			logPrefErr(telemetryOTLPEndpointKey, err)
 		}
-
-		n, keventWaitErr := syscall.Kevent(kq, nil, events, &timeout)
-		if keventWaitErr != nil {
-			if errors.Is(keventWaitErr, syscall.EINTR) {
-				continue
+		if endpoint == "" {
+			return nil
+		}
+		spool := NewTelemetrySpool(telemetrySpoolPath+"-otlp", telemetryMaxSpooledEvents)
+		return newSpooledSink(NewOTLPSink(endpoint, hostname), spool)
+
+	case "elastic":
+		// TelemetryElasticURL (string), TelemetryElasticIndex (string)
+		var elasticURL string
+		elasticIndex := "dnshield-watchdog"
+		if v, err := readPreference(telemetryElasticURLKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				elasticURL = strings.TrimSpace(s)
 			}
-			return fmt.Errorf("kevent wait: %w", keventWaitErr)
+		} else {
+			logPrefErr(telemetryElasticURLKey, err)
 		}
-
-		if n == 0 {
-			continue
+		if v, err := readPreference(telemetryElasticIndexKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				elasticIndex = strings.TrimSpace(s)
+			}
+		} else {
+			logPrefErr(telemetryElasticIndexKey, err)
 		}
-
-		ev := events[0]
-		if ev.Flags&syscall.EV_ERROR != 0 {
-			return fmt.Errorf("kevent error flag set (data=%d)", ev.Data)
+		if elasticURL == "" {
+			return nil
 		}
+		spool := NewTelemetrySpool(telemetrySpoolPath+"-elastic", telemetryMaxSpooledEvents)
+		return newSpooledSink(NewElasticSink(elasticURL, elasticIndex), spool)
 
-		if ev.Fflags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME|syscall.NOTE_REVOKE) != 0 {
-			syscall.Close(fd)
-			var openErr error
-			for {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
-				}
+	case "syslog":
+		// TelemetrySyslogAddress (string, host:port), TelemetrySyslogTLS (bool)
+		var addr string
+		if v, err := readPreference(telemetrySyslogAddressKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				addr = strings.TrimSpace(s)
+			}
+		} else {
+			logPrefErr(telemetrySyslogAddressKey, err)
+		}
+		if addr == "" {
+			return nil
+		}
 
-				fd, openErr = openHostsFile()
-				if openErr == nil {
-					break
+		useTLS := false
+		if v, err := readPreference(telemetrySyslogTLSKey); err == nil {
+			switch b := v.(type) {
+			case bool:
+				useTLS = b
+			case string:
+				if parsed, e := strconv.ParseBool(strings.TrimSpace(b)); e == nil {
+					useTLS = parsed
 				}
-				log.Info().Err(openErr).Msg("waiting for hosts file to reappear")
-				time.Sleep(100 * time.Millisecond)
 			}
+		} else {
+			logPrefErr(telemetrySyslogTLSKey, err)
+		}
 
-			if fd >= 0 {
-				event.Ident = uint64(fd)
-			} else {
-				return fmt.Errorf("invalid file descriptor after reopen: %d", fd)
-			}
-			if _, reregErr := syscall.Kevent(kq, []syscall.Kevent_t{event}, nil, nil); reregErr != nil {
-				return fmt.Errorf("re-register kevent: %w", reregErr)
+		var tlsConfig *tls.Config
+		if useTLS {
+			tlsConfig = telemetryTLSConfig()
+		}
+
+		spool := NewTelemetrySpool(telemetrySpoolPath+"-syslog", telemetryMaxSpooledEvents)
+		return newSpooledSink(NewSyslogSink(addr, tlsConfig, hostname), spool)
+
+	default: // "splunk"
+		// TelemetryHECToken (string), TelemetryServerURL (string)
+		var hecToken, serverURL string
+		if v, err := readPreference(telemetryHECTokenKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				hecToken = strings.TrimSpace(s)
 			}
-			if procErr := processHostsChange(ctx, lastHash); procErr != nil {
-				log.Error().Err(procErr).Msg("error processing hosts change after reopen")
+		} else {
+			logPrefErr(telemetryHECTokenKey, err)
+		}
+		if v, err := readPreference(telemetryServerURLKey); err == nil {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				serverURL = strings.TrimSpace(s)
 			}
-			continue
+		} else {
+			logPrefErr(telemetryServerURLKey, err)
 		}
+		if hecToken == "" || serverURL == "" {
+			return nil
+		}
+		// TelemetryClient does its own batching/retry/spool internally
+		// (see telemetry.go), so it isn't wrapped in spooledSink here.
+		spool := NewTelemetrySpool(telemetrySpoolPath, telemetryMaxSpooledEvents)
+		return NewTelemetryClient(serverURL, hecToken, hostname, telemetryTLSConfig(), spool)
+	}
+}
 
-		if ev.Fflags&(syscall.NOTE_WRITE|syscall.NOTE_EXTEND|syscall.NOTE_ATTRIB) == 0 {
-			continue
+// telemetryTLSConfig builds the TLS configuration shared by telemetry
+// backends that speak TLS (Splunk HEC, and syslog when
+// TelemetrySyslogTLS is set) from the TelemetryTLSInsecureSkipVerify and
+// TelemetryCABundlePath preferences. Verification is on by default -
+// unlike the hardcoded InsecureSkipVerify: true this used to ship with -
+// so skipping it requires an explicit opt-in.
+func telemetryTLSConfig() *tls.Config {
+	cfg := &tls.Config{}
+
+	if v, err := readPreference(telemetryTLSInsecureSkipVerifyKey); err == nil {
+		switch b := v.(type) {
+		case bool:
+			cfg.InsecureSkipVerify = b //nolint:gosec // explicit opt-in via preference
+		case string:
+			if parsed, e := strconv.ParseBool(strings.TrimSpace(b)); e == nil {
+				cfg.InsecureSkipVerify = parsed //nolint:gosec // explicit opt-in via preference
+			}
 		}
+	} else {
+		logPrefErr(telemetryTLSInsecureSkipVerifyKey, err)
+	}
 
-		time.Sleep(100 * time.Millisecond)
+	var caBundlePath string
+	if v, err := readPreference(telemetryCABundlePathKey); err == nil {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			caBundlePath = strings.TrimSpace(s)
+		}
+	} else {
+		logPrefErr(telemetryCABundlePathKey, err)
+	}
 
-		if procErr := processHostsChange(ctx, lastHash); procErr != nil {
-			log.Error().Err(procErr).Msg("error processing hosts change")
+	if caBundlePath != "" {
+		pem, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			log.Warn().Err(err).Str("ca_bundle_path", caBundlePath).Msg("failed to read telemetry CA bundle; using system trust store")
+			return cfg
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			log.Warn().Str("ca_bundle_path", caBundlePath).Msg("telemetry CA bundle contained no usable certificates; using system trust store")
+			return cfg
 		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg
+}
+
+// logPrefErr logs a preference read failure at an appropriate level:
+// debug if the key simply has no value set, error otherwise.
+func logPrefErr(key string, err error) {
+	if err != nil && !errors.Is(err, ErrPrefValueNotFound) {
+		log.Error().Err(err).Str("key", key).Msg("error reading preference")
+	} else if errors.Is(err, ErrPrefValueNotFound) {
+		log.Debug().Str("key", key).Msg("no value found for preference key")
 	}
 }
 
-func openHostsFile() (int, error) {
-	fd, err := syscall.Open(hostsFilePath, syscall.O_EVTONLY, 0)
+func readPreference(prefKey string) (any, error) {
+	value, level, err := managedSource.Value(prefKey)
 	if err != nil {
-		return -1, fmt.Errorf("open hosts file: %w", err)
+		return nil, err
 	}
-	return fd, nil
+	if value != nil {
+		locked := level == managedconfig.LevelManaged && managedSource.IsForced(prefKey)
+		configOrigins[prefKey] = configOrigin{Value: value, Level: level, Locked: locked}
+		if locked {
+			log.Info().Str("key", prefKey).Msg("configuration field locked by MDM; local overrides are ignored")
+		}
+
+		var strValue string
+		switch v := value.(type) {
+		case string:
+			strValue = v
+		case int:
+			strValue = strconv.Itoa(v)
+		case int64:
+			strValue = strconv.FormatInt(v, 10)
+		case float64:
+			strValue = strconv.FormatInt(int64(v), 10)
+		default:
+			log.Debug().
+				Str("key", prefKey).
+				Str("type", fmt.Sprintf("%T", value)).
+				Str("level", level.String()).
+				Msg("unexpected type for preference")
+		}
+		if strValue != "" {
+			os.Setenv(prefKey, strValue)
+		}
+		return value, nil
+	}
+	return nil, ErrPrefValueNotFound
+}
+
+func hashBytes(data []byte) [32]byte {
+	return sha256.Sum256(data)
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// recoveryCooldown is the minimum time Recoverer waits between recovery
+// attempts for the same database, so a file that's flapping (repeatedly
+// removed or tampered with) can't turn into a restore storm.
+const recoveryCooldown = 5 * time.Minute
+
+// errRecoveryRateLimited is returned by Recoverer.Recover when the last
+// attempt was too recent.
+var errRecoveryRateLimited = errors.New("recovery attempt rate-limited")
+
+// SnapshotSource fetches the last-known-good database snapshot along with
+// its detached Ed25519 signature. LocalSnapshotSource is the only
+// implementation this tree ships; fetching from something like S3 or an
+// enterprise config server would need their own client libraries, and
+// neither is a dependency of this module, so those sources aren't
+// implemented here - this interface is the extension point for them.
+type SnapshotSource interface {
+	Fetch(ctx context.Context) (data, signature []byte, err error)
+}
+
+// LocalSnapshotSource reads a signed snapshot from the local filesystem:
+// Path is the database snapshot itself, and Path+".sig" is its detached
+// Ed25519 signature.
+type LocalSnapshotSource struct {
+	Path string
+}
+
+// Fetch implements SnapshotSource.
+func (s *LocalSnapshotSource) Fetch(_ context.Context) ([]byte, []byte, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	sig, err := os.ReadFile(s.Path + ".sig")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read snapshot signature: %w", err)
+	}
+	return data, sig, nil
+}
+
+// Recoverer restores a DatabaseMonitor's database from a signed snapshot
+// when it's found removed or tampered with: the existing file (if any)
+// is quarantined, the snapshot is verified against PublicKey before it's
+// trusted, and the swap into place is atomic.
+type Recoverer struct {
+	Source        SnapshotSource
+	PublicKey     ed25519.PublicKey
+	QuarantineDir string
+
+	mu          sync.Mutex
+	lastAttempt time.Time
+}
+
+// Recover fetches, verifies, and installs a snapshot at dbPath, first
+// quarantining whatever's currently there. It returns
+// errRecoveryRateLimited without attempting anything if the previous
+// attempt was within recoveryCooldown.
+func (r *Recoverer) Recover(ctx context.Context, dbPath string) error {
+	r.mu.Lock()
+	if !r.lastAttempt.IsZero() && time.Since(r.lastAttempt) < recoveryCooldown {
+		r.mu.Unlock()
+		return errRecoveryRateLimited
+	}
+	r.lastAttempt = time.Now()
+	r.mu.Unlock()
+
+	data, sig, err := r.Source.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch snapshot: %w", err)
+	}
+
+	if len(r.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(r.PublicKey, data, sig) {
+		return fmt.Errorf("snapshot signature verification failed")
+	}
+
+	if err := r.quarantine(dbPath); err != nil {
+		return fmt.Errorf("quarantine existing database: %w", err)
+	}
+
+	tmpPath := dbPath + ".recovering"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write recovered snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("install recovered snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// quarantine moves whatever's currently at dbPath aside rather than
+// deleting it, so a tampered or otherwise suspicious file is preserved
+// for later inspection instead of being lost the moment recovery runs.
+func (r *Recoverer) quarantine(dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	dir := r.QuarantineDir
+	if dir == "" {
+		dir = filepath.Dir(dbPath)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	quarantinePath := filepath.Join(dir, filepath.Base(dbPath)+".quarantine-"+time.Now().UTC().Format("20060102T150405Z"))
+	return os.Rename(dbPath, quarantinePath)
+}
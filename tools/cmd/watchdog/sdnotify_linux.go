@@ -0,0 +1,36 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// sdnotify sends a sd_notify(3) datagram to $NOTIFY_SOCKET. It is a no-op
+// (returning nil) when the variable is unset, which is the normal case
+// when watchdog isn't running under systemd.
+func sdnotify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+
+	// A leading '@' denotes a Linux abstract-namespace socket, addressed
+	// with a leading NUL byte instead of the literal '@'.
+	addr := socketPath
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:]
+	}
+
+	conn, err := net.Dial("unixgram", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
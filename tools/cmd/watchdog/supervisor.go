@@ -0,0 +1,25 @@
+package main
+
+// supervisor reports liveness to whatever service manager started this
+// process (launchd on darwin, systemd on linux), so an operator sees real
+// liveness - "process is running but the hosts-file watch loop is wedged"
+// - rather than just a running PID.
+type supervisor interface {
+	// Ready signals that startup has completed and the watch loop is
+	// about to begin.
+	Ready()
+	// Heartbeat signals that the watch loop is still making progress. It
+	// is called after every successful processHostsChange, whether or
+	// not the hosts file actually changed.
+	Heartbeat()
+	// Stopping signals a graceful shutdown is underway.
+	Stopping()
+}
+
+// noopSupervisor is used on platforms with no service-manager integration
+// wired up yet.
+type noopSupervisor struct{}
+
+func (noopSupervisor) Ready()     {}
+func (noopSupervisor) Heartbeat() {}
+func (noopSupervisor) Stopping()  {}
@@ -0,0 +1,83 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// staleHeartbeatMultiple is how many heartbeatInterval periods may elapse
+// with no heartbeat before launchdSupervisor considers the watch loop
+// wedged and exits.
+const staleHeartbeatMultiple = 3
+
+// heartbeatFilePath is touched on every heartbeat so an external health
+// check (or a launchd WatchPaths stanza) can observe liveness without
+// talking to the process directly.
+const heartbeatFilePath = "/var/run/dnshield-watchdog.heartbeat"
+
+// launchdSupervisor integrates with launchd's KeepAlive-on-crash behavior:
+// it has no notify socket to report into, so instead it polices its own
+// liveness and exits non-zero when the hosts-file watch loop stalls,
+// trusting launchd to restart it.
+type launchdSupervisor struct {
+	mu       sync.Mutex
+	lastBeat time.Time
+	interval time.Duration
+	stop     chan struct{}
+}
+
+func newSupervisor() supervisor {
+	s := &launchdSupervisor{
+		lastBeat: time.Now(),
+		interval: heartbeatInterval,
+		stop:     make(chan struct{}),
+	}
+	go s.watchForStall()
+	return s
+}
+
+func (s *launchdSupervisor) Ready() {
+	s.Heartbeat()
+}
+
+func (s *launchdSupervisor) Heartbeat() {
+	s.mu.Lock()
+	s.lastBeat = time.Now()
+	s.mu.Unlock()
+
+	if err := os.WriteFile(heartbeatFilePath, []byte(time.Now().UTC().Format(time.RFC3339)), 0o644); err != nil {
+		log.Warn().Err(err).Str("path", heartbeatFilePath).Msg("failed to write heartbeat file")
+	}
+}
+
+func (s *launchdSupervisor) Stopping() {
+	close(s.stop)
+}
+
+// watchForStall exits the process with a non-zero status if no heartbeat
+// has landed for staleHeartbeatMultiple intervals, so launchd's KeepAlive
+// restarts a wedged daemon instead of leaving it running but useless.
+func (s *launchdSupervisor) watchForStall() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			since := time.Since(s.lastBeat)
+			s.mu.Unlock()
+			if since > s.interval*staleHeartbeatMultiple {
+				log.Fatal().Dur("since_last_heartbeat", since).Msg("hosts-file watch loop appears stalled; exiting for launchd to restart us")
+			}
+		}
+	}
+}
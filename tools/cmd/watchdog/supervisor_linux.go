@@ -0,0 +1,50 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// systemdSupervisor drives sd_notify off the same heartbeat calls
+// launchdSupervisor uses on darwin. Unlike launchd, systemd polices the
+// watchdog timeout itself once WATCHDOG_USEC is honored, so there is no
+// local stall-detection goroutine here.
+type systemdSupervisor struct {
+	watchdogInterval time.Duration
+}
+
+func newSupervisor() supervisor {
+	s := &systemdSupervisor{}
+	if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+		s.watchdogInterval = time.Duration(usec) * time.Microsecond
+	}
+	return s
+}
+
+func (s *systemdSupervisor) Ready() {
+	if err := sdnotify("READY=1\nSTATUS=watching /etc/hosts"); err != nil {
+		log.Debug().Err(err).Msg("sd_notify READY failed")
+	}
+}
+
+func (s *systemdSupervisor) Heartbeat() {
+	state := "STATUS=watching /etc/hosts"
+	if s.watchdogInterval > 0 {
+		state = "WATCHDOG=1\n" + state
+	}
+	if err := sdnotify(state); err != nil {
+		log.Debug().Err(err).Msg("sd_notify WATCHDOG failed")
+	}
+}
+
+func (s *systemdSupervisor) Stopping() {
+	if err := sdnotify("STOPPING=1"); err != nil {
+		log.Debug().Err(err).Msg("sd_notify STOPPING failed")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package main
+
+func newSupervisor() supervisor {
+	return noopSupervisor{}
+}
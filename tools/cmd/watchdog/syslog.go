@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogBackend is implemented per-platform: syslog_darwin.go bridges to
+// os_log, syslog_linux.go to log/syslog, and syslog_other.go is a no-op.
+type syslogBackend interface {
+	Log(level zerolog.Level, msg string) error
+}
+
+// syslogHook forwards log events to the host's native syslog facility,
+// gated by SyslogEnabled, so enterprise deployments can pull watchdog
+// events into their existing log pipeline without standing up an HEC
+// endpoint. It mirrors telemetryHook's shape: a thin zerolog.Hook
+// wrapping a platform-specific sender.
+type syslogHook struct {
+	backend syslogBackend
+}
+
+func (h syslogHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if h.backend == nil {
+		return
+	}
+	if err := h.backend.Log(level, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write syslog entry: %v\n", err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/gemini/dnshield/internal/osloghook"
+	"github.com/rs/zerolog"
+)
+
+// osLogBackend is the darwin syslogBackend, reporting under subsystem
+// preferenceDomain with category syslogTag. A zerolog.Hook only sees the
+// rendered message and level - not the fields already chained onto the
+// event - so unlike the always-on os_log mirror in log_darwin.go, this
+// sink can't vary its category by event_type; it reuses osloghook.Writer
+// by re-wrapping the message/level as the minimal JSON it expects.
+type osLogBackend struct {
+	writer *osloghook.Writer
+}
+
+func newSyslogBackend() syslogBackend {
+	return osLogBackend{writer: osloghook.NewWriter(preferenceDomain, syslogTag)}
+}
+
+func (b osLogBackend) Log(level zerolog.Level, msg string) error {
+	payload, err := json.Marshal(map[string]string{
+		"message": msg,
+		"level":   level.String(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = b.writer.Write(payload)
+	return err
+}
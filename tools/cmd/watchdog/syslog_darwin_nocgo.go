@@ -0,0 +1,12 @@
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package main
+
+// newSyslogBackend has no non-cgo path to bridge into os_log (the real
+// bridge in syslog_darwin.go needs cgo), so a CGO_ENABLED=0 darwin build
+// gets no native syslog integration, the same as syslog_other.go's
+// every-other-platform case.
+func newSyslogBackend() syslogBackend {
+	return nil
+}
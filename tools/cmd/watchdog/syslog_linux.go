@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"log/syslog"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// syslogDialer is the Linux syslogBackend, writing through log/syslog at
+// the configured facility and tag.
+type syslogDialer struct {
+	w *syslog.Writer
+}
+
+func newSyslogBackend() syslogBackend {
+	w, err := syslog.New(parseSyslogFacility(syslogFacility), syslogTag)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to connect to syslog")
+		return nil
+	}
+	return syslogDialer{w: w}
+}
+
+func (b syslogDialer) Log(level zerolog.Level, msg string) error {
+	switch level {
+	case zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel:
+		return b.w.Err(msg)
+	case zerolog.WarnLevel:
+		return b.w.Warning(msg)
+	case zerolog.DebugLevel, zerolog.TraceLevel:
+		return b.w.Debug(msg)
+	default:
+		return b.w.Info(msg)
+	}
+}
+
+// parseSyslogFacility maps a LOG_* facility name (as set via the
+// SyslogFacility preference) to its syslog.Priority, defaulting to
+// LOG_DAEMON - the conventional facility for a background service - when
+// unset or unrecognized.
+func parseSyslogFacility(name string) syslog.Priority {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "LOG_KERN":
+		return syslog.LOG_KERN
+	case "LOG_USER":
+		return syslog.LOG_USER
+	case "LOG_MAIL":
+		return syslog.LOG_MAIL
+	case "LOG_DAEMON":
+		return syslog.LOG_DAEMON
+	case "LOG_AUTH":
+		return syslog.LOG_AUTH
+	case "LOG_SYSLOG":
+		return syslog.LOG_SYSLOG
+	case "LOG_LPR":
+		return syslog.LOG_LPR
+	case "LOG_NEWS":
+		return syslog.LOG_NEWS
+	case "LOG_UUCP":
+		return syslog.LOG_UUCP
+	case "LOG_CRON":
+		return syslog.LOG_CRON
+	case "LOG_AUTHPRIV":
+		return syslog.LOG_AUTHPRIV
+	case "LOG_FTP":
+		return syslog.LOG_FTP
+	case "LOG_LOCAL0":
+		return syslog.LOG_LOCAL0
+	case "LOG_LOCAL1":
+		return syslog.LOG_LOCAL1
+	case "LOG_LOCAL2":
+		return syslog.LOG_LOCAL2
+	case "LOG_LOCAL3":
+		return syslog.LOG_LOCAL3
+	case "LOG_LOCAL4":
+		return syslog.LOG_LOCAL4
+	case "LOG_LOCAL5":
+		return syslog.LOG_LOCAL5
+	case "LOG_LOCAL6":
+		return syslog.LOG_LOCAL6
+	case "LOG_LOCAL7":
+		return syslog.LOG_LOCAL7
+	default:
+		return syslog.LOG_DAEMON
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package main
+
+// newSyslogBackend has no native syslog integration wired up for this
+// platform yet.
+func newSyslogBackend() syslogBackend {
+	return nil
+}
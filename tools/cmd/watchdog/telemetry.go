@@ -1,16 +1,17 @@
-//go:build darwin
-// +build darwin
-
 package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,85 +26,259 @@ type HECEvent struct {
 	Fields     map[string]interface{} `json:"fields,omitempty"`
 }
 
-// TelemetryClient handles sending logs to Splunk HEC.
+// telemetryQueueCapacity, telemetryBatchSize, and telemetryBatchInterval
+// are the size and time triggers that flush a pending HEC batch,
+// whichever comes first - the same pattern OTLPSink and ElasticSink use.
+const (
+	telemetryQueueCapacity  = 500
+	telemetryBatchSize      = 50
+	telemetryBatchInterval  = 5 * time.Second
+	telemetryRequestTimeout = 10 * time.Second
+)
+
+// telemetryMaxRetries, telemetryRetryBase, and telemetryRetryMax bound
+// the exponential backoff (with jitter) a batch is retried with on a
+// 5xx/429 response before it's spilled to disk.
+const (
+	telemetryMaxRetries = 5
+	telemetryRetryBase  = 500 * time.Millisecond
+	telemetryRetryMax   = 30 * time.Second
+)
+
+// TelemetryStats holds Prometheus-style counters for a TelemetryClient's
+// async pipeline.
+type TelemetryStats struct {
+	Enqueued int64
+	Dropped  int64
+	Sent     int64
+	Retried  int64
+}
+
+// TelemetryClient sends logs to Splunk HEC. Events are queued on a
+// bounded channel and batched by a background goroutine, gzipped, and
+// POSTed with exponential-backoff-with-jitter retry (honoring
+// Retry-After) on 5xx/429; a batch that still fails after retrying is
+// spilled to spool rather than dropped.
 type TelemetryClient struct {
 	serverURL  string
 	hecToken   string
 	httpClient *http.Client
 	hostname   string
+	spool      *TelemetrySpool
+
+	events chan map[string]interface{}
+	flush  chan chan error
+	done   chan struct{}
+
+	enqueued int64
+	dropped  int64
+	sent     int64
+	retried  int64
 }
 
-// NewTelemetryClient creates a new telemetry client.
-func NewTelemetryClient(serverURL, hecToken, hostname string) *TelemetryClient {
-	return &TelemetryClient{
+// NewTelemetryClient creates a new telemetry client. tlsConfig controls
+// the HEC connection's TLS verification (nil means the default, secure
+// behavior - not the historical InsecureSkipVerify); spool, if non-nil,
+// is where batches that fail even after retrying are spilled, and is
+// drained back into the client once on construction.
+func NewTelemetryClient(serverURL, hecToken, hostname string, tlsConfig *tls.Config, spool *TelemetrySpool) *TelemetryClient {
+	t := &TelemetryClient{
 		serverURL: serverURL,
 		hecToken:  hecToken,
 		hostname:  hostname,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true, //nolint:gosec // Splunk HEC endpoint
-				},
-			},
+			Timeout:   telemetryRequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
 		},
+		spool:  spool,
+		events: make(chan map[string]interface{}, telemetryQueueCapacity),
+		flush:  make(chan chan error),
+		done:   make(chan struct{}),
+	}
+	go t.run()
+	if spool != nil {
+		if err := spool.Drain(t); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to drain telemetry spool: %v\n", err)
+		}
+	}
+	return t
+}
+
+// SendEvent enqueues event for the next batch. It returns an error
+// rather than blocking if the queue is full.
+func (t *TelemetryClient) SendEvent(event map[string]interface{}) error {
+	select {
+	case t.events <- event:
+		atomic.AddInt64(&t.enqueued, 1)
+		return nil
+	default:
+		atomic.AddInt64(&t.dropped, 1)
+		return fmt.Errorf("telemetry event queue full (capacity %d)", telemetryQueueCapacity)
+	}
+}
+
+// Flush blocks until any pending batch has been sent (or spilled to
+// spool, if sending failed even after retrying).
+func (t *TelemetryClient) Flush() error {
+	reply := make(chan error, 1)
+	t.flush <- reply
+	return <-reply
+}
+
+// Close flushes any pending batch and stops the batching goroutine.
+func (t *TelemetryClient) Close() error {
+	err := t.Flush()
+	close(t.done)
+	return err
+}
+
+// Stats returns a snapshot of the client's enqueued/dropped/sent/retried
+// counters.
+func (t *TelemetryClient) Stats() TelemetryStats {
+	return TelemetryStats{
+		Enqueued: atomic.LoadInt64(&t.enqueued),
+		Dropped:  atomic.LoadInt64(&t.dropped),
+		Sent:     atomic.LoadInt64(&t.sent),
+		Retried:  atomic.LoadInt64(&t.retried),
+	}
+}
+
+func (t *TelemetryClient) run() {
+	ticker := time.NewTicker(telemetryBatchInterval)
+	defer ticker.Stop()
+
+	var batch []map[string]interface{}
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		toSend := batch
+		batch = nil
+		if err := t.sendBatchWithRetry(toSend); err != nil {
+			t.spillToSpool(toSend)
+			return err
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case e := <-t.events:
+			batch = append(batch, e)
+			if len(batch) >= telemetryBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case reply := <-t.flush:
+			reply <- send()
+		case <-t.done:
+			send()
+			return
+		}
+	}
+}
+
+func (t *TelemetryClient) spillToSpool(batch []map[string]interface{}) {
+	if t.spool == nil {
+		return
+	}
+	for _, e := range batch {
+		if err := t.spool.Append(e); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to spool telemetry event: %v\n", err)
+		}
 	}
 }
 
-// SendEvent sends a single event to Splunk HEC.
-func (t *TelemetryClient) SendEvent(event interface{}) error {
+// sendBatchWithRetry POSTs batch to HEC, retrying with exponential
+// backoff and jitter (bounded by telemetryRetryMax, honoring any
+// Retry-After the server sends back) on a 5xx/429 response or a failed
+// request, up to telemetryMaxRetries attempts; any other non-200
+// response is returned at once without retrying.
+func (t *TelemetryClient) sendBatchWithRetry(batch []map[string]interface{}) error {
 	if t.serverURL == "" || t.hecToken == "" {
-		return errors.New("telemetry not configured")
-	}
-
-	hecEvent := HECEvent{
-		Time:       float64(time.Now().Unix()),
-		Event:      event,
-		Host:       t.hostname,
-		Source:     "dnshield-watchdog",
-		SourceType: "_json",
-		Fields: map[string]interface{}{
-			"component": "watchdog",
-			"service":   "dnshield",
-		},
+		return fmt.Errorf("telemetry not configured")
 	}
 
-	jsonData, err := json.Marshal(hecEvent)
+	payload, err := t.encodeBatch(batch)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return fmt.Errorf("encode HEC batch: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.serverURL+"/services/collector/event", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	delay := telemetryRetryBase
+	var lastErr error
+	for attempt := 0; attempt <= telemetryMaxRetries; attempt++ {
+		if attempt > 0 {
+			atomic.AddInt64(&t.retried, 1)
+			time.Sleep(delay)
+		}
+
+		retryAfter, retryable, sendErr := t.postBatch(payload)
+		if sendErr == nil {
+			atomic.AddInt64(&t.sent, int64(len(batch)))
+			return nil
+		}
+		lastErr = sendErr
+		if !retryable {
+			return lastErr
+		}
+
+		if retryAfter > 0 {
+			delay = retryAfter
+		} else {
+			delay = jitter(delay * 2)
+			if delay > telemetryRetryMax {
+				delay = telemetryRetryMax
+			}
+		}
+	}
+	return lastErr
+}
+
+// jitter returns a random duration in [d/2, d], so many watchdogs
+// retrying at once don't all hammer HEC on the same tick.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
 	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2+1)))
+}
 
+// postBatch sends payload to HEC, returning the server's requested
+// Retry-After (if any) and whether the failure is retryable (a 429/5xx
+// response or a failed request) alongside an error for any non-200 result.
+func (t *TelemetryClient) postBatch(payload []byte) (time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.serverURL+"/services/collector/event", bytes.NewReader(payload))
+	if err != nil {
+		return 0, false, fmt.Errorf("create request: %w", err)
+	}
 	req.Header.Set("Authorization", "Splunk "+t.hecToken)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
 
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send event: %w", err)
+		return 0, true, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusOK {
+		return 0, false, nil
 	}
 
-	return nil
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	return retryAfter, retryable, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 }
 
-// SendBatch sends multiple events to Splunk HEC.
-func (t *TelemetryClient) SendBatch(events []interface{}) error {
-	if t.serverURL == "" || t.hecToken == "" {
-		return errors.New("telemetry not configured")
-	}
+// encodeBatch gzips batch as newline-delimited HEC events; HEC accepts a
+// gzipped, multi-event body under Content-Encoding: gzip.
+func (t *TelemetryClient) encodeBatch(batch []map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
 
-	var buffer bytes.Buffer
-	encoder := json.NewEncoder(&buffer)
-
-	for _, event := range events {
+	for _, event := range batch {
 		hecEvent := HECEvent{
 			Time:       float64(time.Now().Unix()),
 			Event:      event,
@@ -115,29 +290,28 @@ func (t *TelemetryClient) SendBatch(events []interface{}) error {
 				"service":   "dnshield",
 			},
 		}
-
-		if err := encoder.Encode(hecEvent); err != nil {
-			return fmt.Errorf("failed to encode event: %w", err)
+		if err := enc.Encode(hecEvent); err != nil {
+			return nil, err
 		}
 	}
-
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.serverURL+"/services/collector/event", &buffer)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := gz.Close(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	req.Header.Set("Authorization", "Splunk "+t.hecToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := t.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send batch: %w", err)
+// parseRetryAfter parses an HTTP Retry-After header, which may be either
+// a number of seconds or an HTTP-date, returning zero if it's absent or
+// unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
 	}
-
-	return nil
+	if when, err := http.ParseTime(v); err == nil {
+		return time.Until(when)
+	}
+	return 0
 }
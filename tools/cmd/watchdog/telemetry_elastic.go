@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// elasticBatchSize and elasticBatchInterval are the size and time
+// triggers that flush a pending Elasticsearch bulk batch, whichever
+// comes first.
+const (
+	elasticBatchSize      = 100
+	elasticBatchInterval  = 5 * time.Second
+	elasticQueueCapacity  = 500
+	elasticRequestTimeout = 10 * time.Second
+)
+
+// ElasticSink exports events to an Elasticsearch (or OpenSearch) index via
+// the _bulk API: a gzip-compressed, newline-delimited sequence of
+// {"index":{}}\n{event}\n pairs. Like OTLPSink, events are queued on a
+// bounded channel and batched by a background goroutine.
+type ElasticSink struct {
+	url        string
+	index      string
+	httpClient *http.Client
+
+	events chan map[string]interface{}
+	flush  chan chan error
+	done   chan struct{}
+}
+
+// NewElasticSink returns an ElasticSink bulk-indexing into index at url
+// and starts its batching loop.
+func NewElasticSink(url, index string) *ElasticSink {
+	s := &ElasticSink{
+		url:        strings.TrimRight(url, "/"),
+		index:      index,
+		httpClient: &http.Client{Timeout: elasticRequestTimeout},
+		events:     make(chan map[string]interface{}, elasticQueueCapacity),
+		flush:      make(chan chan error),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// SendEvent enqueues event for the next bulk batch. It returns an error
+// rather than blocking if the queue is full.
+func (s *ElasticSink) SendEvent(event map[string]interface{}) error {
+	select {
+	case s.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("elasticsearch event queue full (capacity %d)", elasticQueueCapacity)
+	}
+}
+
+// Flush blocks until any pending batch has been indexed.
+func (s *ElasticSink) Flush() error {
+	reply := make(chan error, 1)
+	s.flush <- reply
+	return <-reply
+}
+
+// Close flushes any pending batch and stops the batching goroutine.
+func (s *ElasticSink) Close() error {
+	err := s.Flush()
+	close(s.done)
+	return err
+}
+
+func (s *ElasticSink) run() {
+	ticker := time.NewTicker(elasticBatchInterval)
+	defer ticker.Stop()
+
+	var batch []map[string]interface{}
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.export(batch)
+		batch = nil
+		return err
+	}
+
+	for {
+		select {
+		case e := <-s.events:
+			batch = append(batch, e)
+			if len(batch) >= elasticBatchSize {
+				if err := send(); err != nil {
+					log.Error().Err(err).Msg("failed to bulk-index Elasticsearch batch")
+				}
+			}
+		case <-ticker.C:
+			if err := send(); err != nil {
+				log.Error().Err(err).Msg("failed to bulk-index Elasticsearch batch")
+			}
+		case reply := <-s.flush:
+			reply <- send()
+		case <-s.done:
+			send()
+			return
+		}
+	}
+}
+
+func (s *ElasticSink) export(batch []map[string]interface{}) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	for _, e := range batch {
+		if err := enc.Encode(map[string]interface{}{"index": map[string]interface{}{"_index": s.index}}); err != nil {
+			return fmt.Errorf("encode bulk action line: %w", err)
+		}
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("encode bulk event line: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("compress bulk payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url+"/_bulk", &buf)
+	if err != nil {
+		return fmt.Errorf("create bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send bulk batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected bulk status code: %d", resp.StatusCode)
+	}
+	return nil
+}
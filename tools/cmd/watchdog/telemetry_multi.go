@@ -0,0 +1,57 @@
+package main
+
+// MultiSink fans an event out to every configured TelemetrySink
+// concurrently, so an operator can ship watchdog events to more than one
+// destination at once (e.g. Splunk HEC for the SOC and a syslog collector
+// for the SIEM) without either one slowing the other down.
+type MultiSink struct {
+	sinks []TelemetrySink
+}
+
+// NewMultiSink returns a MultiSink wrapping sinks.
+func NewMultiSink(sinks ...TelemetrySink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// SendEvent dispatches event to every sink concurrently and returns the
+// first error encountered, if any, once all of them have finished.
+func (m *MultiSink) SendEvent(event map[string]interface{}) error {
+	errs := make(chan error, len(m.sinks))
+	for _, sink := range m.sinks {
+		go func(sink TelemetrySink) {
+			errs <- sink.SendEvent(event)
+		}(sink)
+	}
+
+	var firstErr error
+	for range m.sinks {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush flushes every sink and returns the first error encountered, if
+// any.
+func (m *MultiSink) Flush() error {
+	var firstErr error
+	for _, sink := range m.sinks {
+		if err := sink.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes every sink and returns the first error encountered, if
+// any.
+func (m *MultiSink) Close() error {
+	var firstErr error
+	for _, sink := range m.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// otlpBatchSize and otlpBatchInterval are the size and time triggers that
+// flush a pending OTLP batch, whichever comes first.
+const (
+	otlpBatchSize      = 50
+	otlpBatchInterval  = 5 * time.Second
+	otlpQueueCapacity  = 500
+	otlpRequestTimeout = 10 * time.Second
+)
+
+// OTLPSink exports events as OTLP/HTTP logs: a JSON-encoded ResourceLogs
+// payload tagged with hostname/component resource attributes, POSTed to
+// an OTLP logs collector endpoint. Events are queued on a bounded channel
+// and batched by a background goroutine so SendEvent never blocks on the
+// network.
+type OTLPSink struct {
+	endpoint   string
+	hostname   string
+	httpClient *http.Client
+
+	events chan map[string]interface{}
+	flush  chan chan error
+	done   chan struct{}
+}
+
+// NewOTLPSink returns an OTLPSink posting batches to endpoint, tagged
+// with hostname as a resource attribute, and starts its batching loop.
+func NewOTLPSink(endpoint, hostname string) *OTLPSink {
+	s := &OTLPSink{
+		endpoint:   endpoint,
+		hostname:   hostname,
+		httpClient: &http.Client{Timeout: otlpRequestTimeout},
+		events:     make(chan map[string]interface{}, otlpQueueCapacity),
+		flush:      make(chan chan error),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// SendEvent enqueues event for the next batch. It returns an error rather
+// than blocking if the queue is full.
+func (s *OTLPSink) SendEvent(event map[string]interface{}) error {
+	select {
+	case s.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("otlp event queue full (capacity %d)", otlpQueueCapacity)
+	}
+}
+
+// Flush blocks until any pending batch has been exported.
+func (s *OTLPSink) Flush() error {
+	reply := make(chan error, 1)
+	s.flush <- reply
+	return <-reply
+}
+
+// Close flushes any pending batch and stops the batching goroutine.
+func (s *OTLPSink) Close() error {
+	err := s.Flush()
+	close(s.done)
+	return err
+}
+
+func (s *OTLPSink) run() {
+	ticker := time.NewTicker(otlpBatchInterval)
+	defer ticker.Stop()
+
+	var batch []map[string]interface{}
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.export(batch)
+		batch = nil
+		return err
+	}
+
+	for {
+		select {
+		case e := <-s.events:
+			batch = append(batch, e)
+			if len(batch) >= otlpBatchSize {
+				if err := send(); err != nil {
+					log.Error().Err(err).Msg("failed to export OTLP log batch")
+				}
+			}
+		case <-ticker.C:
+			if err := send(); err != nil {
+				log.Error().Err(err).Msg("failed to export OTLP log batch")
+			}
+		case reply := <-s.flush:
+			reply <- send()
+		case <-s.done:
+			send()
+			return
+		}
+	}
+}
+
+func (s *OTLPSink) export(batch []map[string]interface{}) error {
+	records := make([]map[string]interface{}, 0, len(batch))
+	for _, e := range batch {
+		body, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		records = append(records, map[string]interface{}{
+			"timeUnixNano": fmt.Sprintf("%d", time.Now().UnixNano()),
+			"body":         map[string]interface{}{"stringValue": string(body)},
+		})
+	}
+
+	payload := map[string]interface{}{
+		"resourceLogs": []map[string]interface{}{
+			{
+				"resource": map[string]interface{}{
+					"attributes": []map[string]interface{}{
+						{"key": "hostname", "value": map[string]interface{}{"stringValue": s.hostname}},
+						{"key": "component", "value": map[string]interface{}{"stringValue": "dnshield-watchdog"}},
+					},
+				},
+				"scopeLogs": []map[string]interface{}{
+					{"logRecords": records},
+				},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal OTLP payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send OTLP batch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected OTLP status code: %d", resp.StatusCode)
+	}
+	return nil
+}
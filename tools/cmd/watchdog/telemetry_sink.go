@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TelemetrySink is the destination log events are forwarded to once a
+// telemetry backend is configured. TelemetryClient (Splunk HEC), OTLPSink,
+// ElasticSink, SyslogSink, and MultiSink (for fanning out to several of
+// the above at once) all implement it, so telemetryHook doesn't need to
+// know which SIEM it's talking to.
+type TelemetrySink interface {
+	SendEvent(event map[string]interface{}) error
+	Flush() error
+	Close() error
+}
+
+// telemetrySpoolPath is where spooledSink persists events it couldn't
+// deliver, so a prolonged network outage doesn't silently drop bypass
+// events. Drained back into the active sink on the next successful send
+// and again at startup.
+const telemetrySpoolPath = "/var/db/dnshield/telemetry-spool"
+
+// telemetryMaxSpooledEvents bounds the spool to a ring of recent events
+// rather than letting it grow without limit across a long outage.
+const telemetryMaxSpooledEvents = 5000
+
+// telemetryRetryAttempts and telemetryRetryBaseDelay control the
+// exponential backoff spooledSink applies before giving up on a send and
+// falling back to the spool.
+const (
+	telemetryRetryAttempts  = 3
+	telemetryRetryBaseDelay = 500 * time.Millisecond
+)
+
+// spooledSink wraps an inner TelemetrySink with retry-with-backoff and an
+// on-disk fallback spool: a send that still fails after retrying is
+// appended to the spool instead of dropped, and every successful send
+// first drains whatever the spool is still holding.
+type spooledSink struct {
+	inner TelemetrySink
+	spool *TelemetrySpool
+}
+
+// newSpooledSink wraps inner with spool and immediately attempts to drain
+// anything left over from a previous run.
+func newSpooledSink(inner TelemetrySink, spool *TelemetrySpool) *spooledSink {
+	s := &spooledSink{inner: inner, spool: spool}
+	if spool != nil {
+		if err := spool.Drain(inner); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to drain telemetry spool: %v\n", err)
+		}
+	}
+	return s
+}
+
+func (s *spooledSink) SendEvent(event map[string]interface{}) error {
+	if s.spool != nil {
+		// Best-effort: if older spooled events are still waiting, don't let
+		// this one jump the queue.
+		_ = s.spool.Drain(s.inner)
+	}
+
+	err := sendWithRetry(s.inner, event)
+	if err == nil {
+		return nil
+	}
+	if s.spool == nil {
+		return err
+	}
+	if spoolErr := s.spool.Append(event); spoolErr != nil {
+		return fmt.Errorf("send failed (%w) and spool failed: %v", err, spoolErr)
+	}
+	return nil
+}
+
+func (s *spooledSink) Flush() error { return s.inner.Flush() }
+func (s *spooledSink) Close() error { return s.inner.Close() }
+
+// sendWithRetry calls sink.SendEvent, retrying with exponential backoff on
+// failure, up to telemetryRetryAttempts total attempts.
+func sendWithRetry(sink TelemetrySink, event map[string]interface{}) error {
+	delay := telemetryRetryBaseDelay
+	var lastErr error
+	for attempt := 0; attempt < telemetryRetryAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err := sink.SendEvent(event); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// TelemetrySpool persists events as newline-delimited JSON on disk,
+// bounded to maxEvents as a ring (the oldest events are dropped once full)
+// so a long outage can't grow the spool without limit.
+type TelemetrySpool struct {
+	mu        sync.Mutex
+	path      string
+	maxEvents int
+}
+
+// NewTelemetrySpool returns a spool backed by the file at path.
+func NewTelemetrySpool(path string, maxEvents int) *TelemetrySpool {
+	return &TelemetrySpool{path: path, maxEvents: maxEvents}
+}
+
+// Append persists event to the spool, trimming the oldest entries if the
+// spool would otherwise exceed maxEvents.
+func (s *TelemetrySpool) Append(event map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create telemetry spool directory: %w", err)
+	}
+
+	lines, err := s.readLinesLocked()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal spooled event: %w", err)
+	}
+	lines = append(lines, string(data))
+	if len(lines) > s.maxEvents {
+		lines = lines[len(lines)-s.maxEvents:]
+	}
+
+	return s.writeLinesLocked(lines)
+}
+
+// Drain replays every spooled event through sink, in order, stopping at
+// the first failure so the rest stay spooled for the next attempt.
+func (s *TelemetrySpool) Drain(sink TelemetrySink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines, err := s.readLinesLocked()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	sent := 0
+	for _, line := range lines {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			// Drop entries we can't parse rather than block the spool on them forever.
+			sent++
+			continue
+		}
+		if err := sink.SendEvent(event); err != nil {
+			break
+		}
+		sent++
+	}
+	if sent == 0 {
+		return nil
+	}
+	return s.writeLinesLocked(lines[sent:])
+}
+
+func (s *TelemetrySpool) readLinesLocked() ([]string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read telemetry spool: %w", err)
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" {
+		return nil, nil
+	}
+	return strings.Split(trimmed, "\n"), nil
+}
+
+func (s *TelemetrySpool) writeLinesLocked(lines []string) error {
+	if len(lines) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove drained telemetry spool: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(s.path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write telemetry spool: %w", err)
+	}
+	return nil
+}
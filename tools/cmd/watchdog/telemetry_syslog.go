@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// syslogQueueCapacity and syslogDialTimeout bound the SyslogSink's
+// backpressure and reconnect behavior, mirroring OTLPSink/ElasticSink's
+// queue-capacity constants.
+const (
+	syslogQueueCapacity = 500
+	syslogDialTimeout   = 10 * time.Second
+)
+
+// syslogFacilityCode is the RFC 5424 facility dnshield-watchdog events are
+// tagged with (16 = local0, the conventional default for application
+// logging that isn't one of the reserved kernel/mail/etc. facilities).
+const syslogFacilityCode = 16
+
+// SyslogSink ships events to a remote collector as RFC 5424 syslog
+// messages over TCP, optionally wrapped in TLS. Unlike OTLPSink and
+// ElasticSink it doesn't batch: syslog is a message-oriented protocol, so
+// each event is framed and written as its own message over a persistent
+// connection that's redialed on write failure. Events are still queued on
+// a bounded channel so SendEvent never blocks on the network.
+type SyslogSink struct {
+	addr      string
+	tlsConfig *tls.Config
+	hostname  string
+
+	events chan map[string]interface{}
+	flush  chan chan error
+	done   chan struct{}
+}
+
+// NewSyslogSink returns a SyslogSink dialing addr (host:port). If
+// tlsConfig is non-nil, the connection is wrapped in TLS; otherwise it's
+// plain TCP. It starts its sending loop immediately.
+func NewSyslogSink(addr string, tlsConfig *tls.Config, hostname string) *SyslogSink {
+	s := &SyslogSink{
+		addr:      addr,
+		tlsConfig: tlsConfig,
+		hostname:  hostname,
+		events:    make(chan map[string]interface{}, syslogQueueCapacity),
+		flush:     make(chan chan error),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// SendEvent enqueues event to be shipped. It returns an error rather than
+// blocking if the queue is full.
+func (s *SyslogSink) SendEvent(event map[string]interface{}) error {
+	select {
+	case s.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("syslog event queue full (capacity %d)", syslogQueueCapacity)
+	}
+}
+
+// Flush is a no-op: SyslogSink writes each event as it's dequeued rather
+// than batching, so there's never a pending batch to wait on.
+func (s *SyslogSink) Flush() error {
+	reply := make(chan error, 1)
+	s.flush <- reply
+	return <-reply
+}
+
+// Close stops the sending loop and closes the underlying connection.
+func (s *SyslogSink) Close() error {
+	err := s.Flush()
+	close(s.done)
+	return err
+}
+
+func (s *SyslogSink) run() {
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	send := func(event map[string]interface{}) error {
+		msg, err := encodeRFC5424(event, s.hostname)
+		if err != nil {
+			return fmt.Errorf("encode syslog message: %w", err)
+		}
+
+		if conn == nil {
+			c, dialErr := s.dial()
+			if dialErr != nil {
+				return dialErr
+			}
+			conn = c
+		}
+
+		if _, err := conn.Write(msg); err != nil {
+			conn.Close()
+			conn = nil
+			return fmt.Errorf("write syslog message: %w", err)
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case e := <-s.events:
+			if err := send(e); err != nil {
+				log.Error().Err(err).Msg("failed to ship syslog event")
+			}
+		case reply := <-s.flush:
+			reply <- nil
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *SyslogSink) dial() (net.Conn, error) {
+	if s.tlsConfig != nil {
+		return tls.DialWithDialer(&net.Dialer{Timeout: syslogDialTimeout}, "tcp", s.addr, s.tlsConfig)
+	}
+	return net.DialTimeout("tcp", s.addr, syslogDialTimeout)
+}
+
+// encodeRFC5424 formats event as an RFC 5424 syslog message
+// (<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA
+// MSG), octet-counted per RFC 6587 framing so messages survive back-to-back
+// on the same TCP connection without a delimiter.
+func encodeRFC5424(event map[string]interface{}, hostname string) ([]byte, error) {
+	severity := 6 // informational, for an event with no "level" field
+	if lvl, ok := event["level"].(string); ok {
+		severity = syslogSeverityFor(lvl)
+	}
+	pri := syslogFacilityCode*8 + severity
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s dnshield-watchdog %d - - %s",
+		pri, time.Now().UTC().Format(time.RFC3339), hostname, os.Getpid(), body)
+
+	framed := fmt.Sprintf("%d %s", len(msg), msg)
+	return []byte(framed), nil
+}
+
+func syslogSeverityFor(level string) int {
+	switch level {
+	case "fatal", "panic":
+		return 2 // critical
+	case "error":
+		return 3
+	case "warn":
+		return 4
+	case "info":
+		return 6
+	case "debug", "trace":
+		return 7
+	default:
+		return 6
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gemini/dnshield/internal/fsmonitor"
+	"github.com/rs/zerolog/log"
+)
+
+// watchHostsFile starts an fsmonitor.Monitor on hostsFilePath and calls
+// processHostsChange each time it reports a change, and also once every
+// heartbeatInterval (so the supervisor keeps hearing from an idle but
+// healthy loop), until ctx is canceled. fsmonitor handles the platform-specific watch mechanism (and
+// falling back to polling if that mechanism fails to start), so this is
+// just the wiring between its events and the watchdog's enforcement
+// logic.
+func watchHostsFile(ctx context.Context, lastHash *[32]byte) error {
+	mon := fsmonitor.NewDefault(hostsFilePath)
+	mon.Start(ctx)
+
+	changes, cancel := mon.Subscribe()
+	defer cancel()
+
+	rescan := time.NewTicker(heartbeatInterval)
+	defer rescan.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("shutting down (context canceled)")
+			return ctx.Err()
+
+		case _, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			if err := processHostsChange(ctx, lastHash); err != nil {
+				log.Error().Err(err).Msg("error processing hosts change")
+			}
+
+		case <-rescan.C:
+			if err := processHostsChange(ctx, lastHash); err != nil {
+				log.Error().Err(err).Msg("error re-scanning hosts file")
+			}
+
+		case watchErr, ok := <-mon.Errors():
+			if !ok {
+				continue
+			}
+			log.Warn().Err(watchErr).Msg("native hosts-file watcher unavailable, falling back to polling")
+		}
+	}
+}
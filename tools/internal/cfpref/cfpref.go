@@ -7,18 +7,12 @@ package cfpref
 many thanks
 
 	https://gist.githubusercontent.com/clburlison/62394d6b0950040ac95586642413f684/raw/fccbf14550cac1effd8868ff4651cbb94e4064a5/cfpref.go
-*/
-
-import (
-	"unsafe"
-)
 
-/*
-#cgo darwin CFLAGS: -DDARWIN -x objective-c
-#cgo LDFLAGS: -framework CoreFoundation
-#include <CoreFoundation/CoreFoundation.h>
+This package used to be a thin cgo wrapper around CoreFoundation. It has
+since been rewritten on top of the cgo-less primitives in
+corefoundation_darwin.go so that dnshield's darwin binaries can be built
+with CGO_ENABLED=0 and no Xcode/clang toolchain.
 */
-import "C"
 
 const (
 	Unknown = "unknown"
@@ -35,87 +29,31 @@ func CFPreferencesCopyAppValueAndType(key, domain string) (interface{}, string)
 	return cFPreferencesCopyAppValue(key, domain)
 }
 
+// cFPreferencesCopyAppValue decodes ret via goValueFromCFPlistRef, so
+// arrays and dictionaries (e.g. a blocklist-domain array or a
+// per-profile-name dictionary, both routine in real preference plists)
+// come back fully recursively decoded instead of as "unknown" the way
+// the original hand-rolled scalar-only switch left them.
 func cFPreferencesCopyAppValue(key string, domain string) (interface{}, string) {
 	k := stringToCFString(key)
-	defer release(C.CFTypeRef(k))
+	defer cfRelease(k)
 	d := stringToCFString(domain)
-	defer release(C.CFTypeRef(d))
+	defer cfRelease(d)
 
 	// Get the preference value once
-	ret := C.CFPreferencesCopyAppValue(k, d)
+	ret := cfPreferencesCopyAppValue(k, d)
 	if ret == 0 {
 		return nil, Unknown
 	}
-	defer release(ret)
+	defer cfRelease(ret)
 
-	typeID := C.CFGetTypeID(ret)
-
-	if typeID == C.CFStringGetTypeID() {
-		return cfstringToString(C.CFStringRef(ret)), "string"
-	}
-	if typeID == C.CFBooleanGetTypeID() {
-		return cfbooleanToBoolean(C.CFBooleanRef(ret)), "boolean"
-	}
-	if typeID == C.CFDataGetTypeID() {
-		return cfdataToData(C.CFDataRef(ret)), "data"
-	}
-	if typeID == C.CFNumberGetTypeID() {
-		return cfInttoInt(C.CFNumberRef(ret)), "number"
-	}
-	return nil, Unknown
-}
-
-func release(ref C.CFTypeRef) {
-	if ref != 0 {
-		C.CFRelease(ref)
-	}
-}
-
-// Convert a Go string to a CFString
-// Make sure to release the CFString when finished.
-func stringToCFString(s string) C.CFStringRef {
-	return C.CFStringCreateWithCString(C.kCFAllocatorDefault, C.CString(s), C.kCFStringEncodingUTF8)
-}
-
-// Convert a CFString to a Go string.
-func cfstringToString(s C.CFStringRef) string {
-	// Try the direct method first
-	if ptr := C.CFStringGetCStringPtr(s, C.kCFStringEncodingUTF8); ptr != nil {
-		return C.GoString(ptr)
-	}
-
-	// Fallback to copying the string
-	length := C.CFStringGetLength(s)
-	if length == 0 {
-		return ""
+	typeName := cfTypeName(ret)
+	if typeName == Unknown {
+		return nil, Unknown
 	}
-
-	// Allocate buffer
-	bufferSize := length*4 + 1 // UTF-8 max 4 bytes per char + null terminator
-	buffer := (*C.char)(C.malloc(C.size_t(bufferSize)))
-	defer C.free(unsafe.Pointer(buffer))
-
-	if C.CFStringGetCString(s, buffer, bufferSize, C.kCFStringEncodingUTF8) != 0 {
-		return C.GoString(buffer)
+	value, err := goValueFromCFPlistRef(ret)
+	if err != nil {
+		return nil, Unknown
 	}
-
-	return ""
-}
-
-// Convert a CFBoolean to a Go bool.
-func cfbooleanToBoolean(s C.CFBooleanRef) bool {
-	return s == C.kCFBooleanTrue
-}
-
-// Convert a CFData to a Go byte.
-func cfdataToData(s C.CFDataRef) []uint8 {
-	d := C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(s)), C.int(C.CFDataGetLength(s)))
-	return d
-}
-
-func cfInttoInt(ref C.CFNumberRef) int {
-	var n int
-	numberType := C.CFNumberGetType(ref)
-	C.CFNumberGetValue(ref, numberType, unsafe.Pointer(&n))
-	return n
+	return value, typeName
 }
@@ -0,0 +1,50 @@
+//go:build darwin
+// +build darwin
+
+package cfpref
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// TestCFPreferencesCopyAppValueNestedPlist round-trips a plist written
+// via `defaults write` containing a nested array and dictionary -
+// realistic preference shapes (an allowlisted-domain array, a
+// per-profile-name dictionary) the scalar-only decoder this package
+// used to have couldn't read at all.
+func TestCFPreferencesCopyAppValueNestedPlist(t *testing.T) {
+	domain := "com.dnshield.cfpref.test"
+	t.Cleanup(func() {
+		_ = exec.Command("defaults", "delete", domain).Run()
+	})
+
+	if err := exec.Command("defaults", "write", domain, "AllowedDomains", "-array", "example.com", "example.org").Run(); err != nil {
+		t.Fatalf("defaults write (array) failed: %v", err)
+	}
+	if err := exec.Command("defaults", "write", domain, "Profiles",
+		"-dict", "corp", "enabled").Run(); err != nil {
+		t.Fatalf("defaults write (dict) failed: %v", err)
+	}
+
+	arrayValue, arrayType := CFPreferencesCopyAppValueAndType("AllowedDomains", domain)
+	if arrayType != "array" {
+		t.Fatalf("AllowedDomains type = %q, want array", arrayType)
+	}
+	domains, ok := arrayValue.([]interface{})
+	if !ok || len(domains) != 2 {
+		t.Fatalf("AllowedDomains = %#v, want 2-element []interface{}", arrayValue)
+	}
+
+	dictValue, dictType := CFPreferencesCopyAppValueAndType("Profiles", domain)
+	if dictType != "dictionary" {
+		t.Fatalf("Profiles type = %q, want dictionary", dictType)
+	}
+	profiles, ok := dictValue.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Profiles = %#v, want map[string]interface{}", dictValue)
+	}
+	if _, ok := profiles["corp"]; !ok {
+		t.Fatalf("Profiles missing nested key %q: %#v", "corp", profiles)
+	}
+}
@@ -0,0 +1,494 @@
+//go:build darwin
+// +build darwin
+
+// Package cfpref reads (and writes) values from the macOS CoreFoundation
+// preferences system.
+//
+// This file declares the slice of the CoreFoundation C API the package
+// needs. Each symbol is imported dynamically at link time with
+// go:cgo_import_dynamic instead of through cgo's `import "C"`, and called
+// through a tiny per-symbol assembly trampoline that jumps straight to the
+// resolved address. This is the same technique used by the Go standard
+// library's crypto/x509/internal/macos package, and it means this package
+// (and anything built on top of it) no longer requires cgo, Xcode, or
+// clang to build: `CGO_ENABLED=0 GOOS=darwin GOARCH=arm64 go build` just
+// works from any host.
+package cfpref
+
+import (
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// CFRef is an opaque reference to a Core Foundation object. It is always
+// pointer-sized but is not a valid Go pointer and must never be
+// dereferenced directly from Go; treat it as a handle to be passed back
+// into CoreFoundation calls and released with cfRelease.
+type CFRef uintptr
+
+const coreFoundationPath = "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+// trampolinePC returns the entry address of a trampoline function defined
+// in trampolines_darwin.s, suitable for passing to syscall9 as its fn
+// argument. A Go func value is a pointer to a funcval struct whose first
+// word is the function's entry PC; for a body-less (assembly-implemented)
+// function like these trampolines there is no ABIInternal wrapper to
+// account for, so this is safe.
+func trampolinePC(fn func()) uintptr {
+	return **(**uintptr)(unsafe.Pointer(&fn))
+}
+
+//go:cgo_import_dynamic cfpref_CFRelease CFRelease "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfRelease_trampoline()
+
+func cfRelease(ref CFRef) {
+	if ref == 0 {
+		return
+	}
+	syscall9(trampolinePC(cfRelease_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFGetTypeID CFGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfGetTypeID_trampoline()
+
+func cfGetTypeID(ref CFRef) uintptr {
+	return syscall9(trampolinePC(cfGetTypeID_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFStringGetTypeID CFStringGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfStringGetTypeID_trampoline()
+
+func cfStringGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfStringGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFBooleanGetTypeID CFBooleanGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfBooleanGetTypeID_trampoline()
+
+func cfBooleanGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfBooleanGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFNumberGetTypeID CFNumberGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfNumberGetTypeID_trampoline()
+
+func cfNumberGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfNumberGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFDataGetTypeID CFDataGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDataGetTypeID_trampoline()
+
+func cfDataGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfDataGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFStringCreateWithBytes CFStringCreateWithBytes "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfStringCreateWithBytes_trampoline()
+
+// cfStringEncodingUTF8 is kCFStringEncodingUTF8, a fixed numeric constant
+// (not a linked symbol) per CFString.h.
+const cfStringEncodingUTF8 = 0x08000100
+
+// stringToCFString converts a Go string to a CFStringRef. The caller must
+// release it with cfRelease.
+func stringToCFString(s string) CFRef {
+	var p unsafe.Pointer
+	if len(s) > 0 {
+		p = unsafe.Pointer(unsafe.StringData(s))
+	}
+	ref := syscall9(trampolinePC(cfStringCreateWithBytes_trampoline),
+		0, // kCFAllocatorDefault (NULL is documented as equivalent)
+		uintptr(p),
+		uintptr(len(s)),
+		cfStringEncodingUTF8,
+		0, // isExternalRepresentation: false
+		0, 0, 0, 0)
+	runtime.KeepAlive(s)
+	return CFRef(ref)
+}
+
+//go:cgo_import_dynamic cfpref_CFStringGetLength CFStringGetLength "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfStringGetLength_trampoline()
+
+func cfStringGetLength(ref CFRef) int {
+	return int(syscall9(trampolinePC(cfStringGetLength_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0))
+}
+
+//go:cgo_import_dynamic cfpref_CFStringGetBytes CFStringGetBytes "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfStringGetBytes_trampoline()
+
+// cfStringToString converts a CFStringRef to a Go string.
+func cfStringToString(ref CFRef) string {
+	length := cfStringGetLength(ref)
+	if length == 0 {
+		return ""
+	}
+
+	buf := make([]byte, length*4) // UTF-8 is at most 4 bytes/UTF-16 unit
+	var used uintptr
+	syscall9(trampolinePC(cfStringGetBytes_trampoline),
+		uintptr(ref),
+		0, uintptr(length), // CFRange{location: 0, length: length}
+		cfStringEncodingUTF8,
+		0, // lossByte: none
+		0, // isExternalRepresentation: false
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)),
+		uintptr(unsafe.Pointer(&used)))
+
+	s := string(buf[:used])
+	runtime.KeepAlive(buf)
+	return s
+}
+
+//go:cgo_import_dynamic cfpref_CFBooleanGetValue CFBooleanGetValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfBooleanGetValue_trampoline()
+
+func cfBooleanToBoolean(ref CFRef) bool {
+	ret := syscall9(trampolinePC(cfBooleanGetValue_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+	return ret&0xff != 0
+}
+
+//go:cgo_import_dynamic cfpref_CFDataGetBytePtr CFDataGetBytePtr "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDataGetBytePtr_trampoline()
+
+//go:cgo_import_dynamic cfpref_CFDataGetLength CFDataGetLength "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDataGetLength_trampoline()
+
+func cfDataToData(ref CFRef) []uint8 {
+	length := int(syscall9(trampolinePC(cfDataGetLength_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0))
+	if length == 0 {
+		return []uint8{}
+	}
+	ptr := syscall9(trampolinePC(cfDataGetBytePtr_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+	view := unsafe.Slice((*byte)(unsafe.Pointer(ptr)), length)
+	out := make([]byte, length)
+	copy(out, view)
+	return out
+}
+
+const (
+	cfNumberSInt64Type  = 4 // kCFNumberSInt64Type
+	cfNumberFloat64Type = 6 // kCFNumberFloat64Type
+)
+
+//go:cgo_import_dynamic cfpref_CFNumberGetValue CFNumberGetValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfNumberGetValue_trampoline()
+
+//go:cgo_import_dynamic cfpref_CFNumberIsFloatType CFNumberIsFloatType "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfNumberIsFloatType_trampoline()
+
+func cfNumberIsFloatType(ref CFRef) bool {
+	ret := syscall9(trampolinePC(cfNumberIsFloatType_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+	return ret&0xff != 0
+}
+
+// cfNumberToValue converts a CFNumberRef to a Go int, or a float64 if the
+// number is backed by a floating point type.
+func cfNumberToValue(ref CFRef) interface{} {
+	if cfNumberIsFloatType(ref) {
+		var f float64
+		syscall9(trampolinePC(cfNumberGetValue_trampoline),
+			uintptr(ref), cfNumberFloat64Type, uintptr(unsafe.Pointer(&f)),
+			0, 0, 0, 0, 0, 0)
+		return f
+	}
+	var n int64
+	syscall9(trampolinePC(cfNumberGetValue_trampoline),
+		uintptr(ref), cfNumberSInt64Type, uintptr(unsafe.Pointer(&n)),
+		0, 0, 0, 0, 0, 0)
+	return int(n)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesCopyAppValue CFPreferencesCopyAppValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesCopyAppValue_trampoline()
+
+func cfPreferencesCopyAppValue(key, applicationID CFRef) CFRef {
+	ret := syscall9(trampolinePC(cfPreferencesCopyAppValue_trampoline), uintptr(key), uintptr(applicationID), 0, 0, 0, 0, 0, 0, 0)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesCopyValue CFPreferencesCopyValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesCopyValue_trampoline()
+
+func cfPreferencesCopyValue(key, applicationID, user, host CFRef) CFRef {
+	ret := syscall9(trampolinePC(cfPreferencesCopyValue_trampoline), uintptr(key), uintptr(applicationID), uintptr(user), uintptr(host), 0, 0, 0, 0, 0)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesAppValueIsForced CFPreferencesAppValueIsForced "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesAppValueIsForced_trampoline()
+
+func cfPreferencesAppValueIsForced(key, applicationID CFRef) bool {
+	ret := syscall9(trampolinePC(cfPreferencesAppValueIsForced_trampoline), uintptr(key), uintptr(applicationID), 0, 0, 0, 0, 0, 0, 0)
+	return ret&0xff != 0
+}
+
+// kCFPreferencesAnyHost etc. are exported by CoreFoundation as plain data
+// symbols (CFStringRef constants), not functions, so they are imported
+// directly rather than called through a trampoline: the dynamic linker
+// resolves the Go variable to the same storage as the C global, and
+// reading it yields the CFStringRef value itself.
+
+//go:cgo_import_dynamic cfpref_kCFPreferencesAnyHost kCFPreferencesAnyHost "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFPreferencesAnyHost uintptr
+
+func kCFPreferencesAnyHost() CFRef {
+	return CFRef(cfpref_kCFPreferencesAnyHost)
+}
+
+//go:cgo_import_dynamic cfpref_kCFPreferencesCurrentHost kCFPreferencesCurrentHost "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFPreferencesCurrentHost uintptr
+
+func kCFPreferencesCurrentHost() CFRef {
+	return CFRef(cfpref_kCFPreferencesCurrentHost)
+}
+
+//go:cgo_import_dynamic cfpref_kCFPreferencesAnyUser kCFPreferencesAnyUser "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFPreferencesAnyUser uintptr
+
+func kCFPreferencesAnyUser() CFRef {
+	return CFRef(cfpref_kCFPreferencesAnyUser)
+}
+
+//go:cgo_import_dynamic cfpref_CFArrayGetTypeID CFArrayGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfArrayGetTypeID_trampoline()
+
+func cfArrayGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfArrayGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFDictionaryGetTypeID CFDictionaryGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDictionaryGetTypeID_trampoline()
+
+func cfDictionaryGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfDictionaryGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFDateGetTypeID CFDateGetTypeID "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDateGetTypeID_trampoline()
+
+func cfDateGetTypeID() uintptr {
+	return syscall9(trampolinePC(cfDateGetTypeID_trampoline), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFArrayGetCount CFArrayGetCount "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfArrayGetCount_trampoline()
+
+func cfArrayGetCount(ref CFRef) int {
+	return int(syscall9(trampolinePC(cfArrayGetCount_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0))
+}
+
+//go:cgo_import_dynamic cfpref_CFArrayGetValueAtIndex CFArrayGetValueAtIndex "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfArrayGetValueAtIndex_trampoline()
+
+func cfArrayGetValueAtIndex(ref CFRef, index int) CFRef {
+	return CFRef(syscall9(trampolinePC(cfArrayGetValueAtIndex_trampoline), uintptr(ref), uintptr(index), 0, 0, 0, 0, 0, 0, 0))
+}
+
+//go:cgo_import_dynamic cfpref_CFDictionaryGetCount CFDictionaryGetCount "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDictionaryGetCount_trampoline()
+
+func cfDictionaryGetCount(ref CFRef) int {
+	return int(syscall9(trampolinePC(cfDictionaryGetCount_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0))
+}
+
+//go:cgo_import_dynamic cfpref_CFDictionaryGetKeysAndValues CFDictionaryGetKeysAndValues "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDictionaryGetKeysAndValues_trampoline()
+
+// cfDictionaryGetKeysAndValues returns the keys and values of a
+// CFDictionaryRef as parallel slices, in the same (unspecified) order
+// CoreFoundation enumerates them in.
+func cfDictionaryGetKeysAndValues(ref CFRef) (keys, values []CFRef) {
+	count := cfDictionaryGetCount(ref)
+	if count == 0 {
+		return nil, nil
+	}
+	keys = make([]CFRef, count)
+	values = make([]CFRef, count)
+	syscall9(trampolinePC(cfDictionaryGetKeysAndValues_trampoline),
+		uintptr(ref), uintptr(unsafe.Pointer(&keys[0])), uintptr(unsafe.Pointer(&values[0])),
+		0, 0, 0, 0, 0, 0)
+	return keys, values
+}
+
+//go:cgo_import_dynamic cfpref_CFDateGetAbsoluteTime CFDateGetAbsoluteTime "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDateGetAbsoluteTime_trampoline()
+
+// cfMacEpochOffset is the number of seconds between the Unix epoch
+// (1970-01-01) and the CFAbsoluteTime/Mac epoch (2001-01-01), the
+// reference point CFDateGetAbsoluteTime's return value is relative to.
+const cfMacEpochOffset = 978307200
+
+func cfDateToTime(ref CFRef) time.Time {
+	secs := syscall9f(trampolinePC(cfDateGetAbsoluteTime_trampoline), uintptr(ref), 0, 0, 0, 0, 0, 0, 0, 0)
+	return time.Unix(int64(secs)+cfMacEpochOffset, 0).UTC()
+}
+
+// kCFBooleanTrue/False are, like kCFPreferencesAnyHost, exported data
+// symbols rather than functions.
+
+//go:cgo_import_dynamic cfpref_kCFBooleanTrue kCFBooleanTrue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFBooleanTrue uintptr
+
+//go:cgo_import_dynamic cfpref_kCFBooleanFalse kCFBooleanFalse "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFBooleanFalse uintptr
+
+func cfBooleanFromBool(b bool) CFRef {
+	if b {
+		return CFRef(cfpref_kCFBooleanTrue)
+	}
+	return CFRef(cfpref_kCFBooleanFalse)
+}
+
+//go:cgo_import_dynamic cfpref_CFNumberCreate CFNumberCreate "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfNumberCreate_trampoline()
+
+func cfNumberCreateInt(n int64) CFRef {
+	ret := syscall9(trampolinePC(cfNumberCreate_trampoline), 0, cfNumberSInt64Type, uintptr(unsafe.Pointer(&n)), 0, 0, 0, 0, 0, 0)
+	runtime.KeepAlive(&n)
+	return CFRef(ret)
+}
+
+func cfNumberCreateFloat(f float64) CFRef {
+	ret := syscall9(trampolinePC(cfNumberCreate_trampoline), 0, cfNumberFloat64Type, uintptr(unsafe.Pointer(&f)), 0, 0, 0, 0, 0, 0)
+	runtime.KeepAlive(&f)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFDataCreate CFDataCreate "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDataCreate_trampoline()
+
+func cfDataCreate(b []byte) CFRef {
+	var p unsafe.Pointer
+	if len(b) > 0 {
+		p = unsafe.Pointer(&b[0])
+	}
+	ret := syscall9(trampolinePC(cfDataCreate_trampoline), 0, uintptr(p), uintptr(len(b)), 0, 0, 0, 0, 0, 0)
+	runtime.KeepAlive(b)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFArrayCreateMutable CFArrayCreateMutable "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfArrayCreateMutable_trampoline()
+
+// cfArrayCreateMutable creates a CFMutableArrayRef with NULL callbacks, so
+// it holds its elements as plain, non-retained pointers. Safe here because
+// every value appended to it is released by the same call tree that built
+// it, after CoreFoundation has finished consuming the array (mirrors the
+// technique crypto/x509/internal/macos uses for the same reason).
+func cfArrayCreateMutable() CFRef {
+	ret := syscall9(trampolinePC(cfArrayCreateMutable_trampoline), 0, 0, 0 /* kCFTypeArrayCallBacks */, 0, 0, 0, 0, 0, 0)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFArrayAppendValue CFArrayAppendValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfArrayAppendValue_trampoline()
+
+func cfArrayAppendValue(array, value CFRef) {
+	syscall9(trampolinePC(cfArrayAppendValue_trampoline), uintptr(array), uintptr(value), 0, 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFDictionaryCreateMutable CFDictionaryCreateMutable "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDictionaryCreateMutable_trampoline()
+
+// cfDictionaryCreateMutable creates a CFMutableDictionaryRef with NULL
+// callbacks for the same reason cfArrayCreateMutable does.
+func cfDictionaryCreateMutable() CFRef {
+	ret := syscall9(trampolinePC(cfDictionaryCreateMutable_trampoline), 0, 0, 0, 0 /* key/value callbacks */, 0, 0, 0, 0, 0)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFDictionarySetValue CFDictionarySetValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDictionarySetValue_trampoline()
+
+func cfDictionarySetValue(dict, key, value CFRef) {
+	syscall9(trampolinePC(cfDictionarySetValue_trampoline), uintptr(dict), uintptr(key), uintptr(value), 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFDateCreate CFDateCreate "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfDateCreate_trampoline()
+
+// cfDateCreate converts t to a CFDateRef. CFDateCreate takes its
+// CFAbsoluteTime argument in a floating-point register rather than a
+// general-purpose one, which syscall9 has no way to pass, so this one
+// call site goes through syscallDateCreate instead - a narrow trampoline
+// built for exactly this (allocator, double) signature.
+func cfDateCreate(t time.Time) CFRef {
+	secs := float64(t.Unix()-cfMacEpochOffset) + float64(t.Nanosecond())/1e9
+	ret := syscallDateCreate(trampolinePC(cfDateCreate_trampoline), 0, secs)
+	return CFRef(ret)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesSetAppValue CFPreferencesSetAppValue "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesSetAppValue_trampoline()
+
+func cfPreferencesSetAppValue(key, value, applicationID CFRef) {
+	syscall9(trampolinePC(cfPreferencesSetAppValue_trampoline), uintptr(key), uintptr(value), uintptr(applicationID), 0, 0, 0, 0, 0, 0)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesAppSynchronize CFPreferencesAppSynchronize "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesAppSynchronize_trampoline()
+
+func cfPreferencesAppSynchronize(applicationID CFRef) bool {
+	ret := syscall9(trampolinePC(cfPreferencesAppSynchronize_trampoline), uintptr(applicationID), 0, 0, 0, 0, 0, 0, 0, 0)
+	return ret&0xff != 0
+}
+
+//go:cgo_import_dynamic cfpref_kCFPreferencesCurrentUser kCFPreferencesCurrentUser "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+var cfpref_kCFPreferencesCurrentUser uintptr
+
+func kCFPreferencesCurrentUser() CFRef {
+	return CFRef(cfpref_kCFPreferencesCurrentUser)
+}
+
+//go:cgo_import_dynamic cfpref_CFPreferencesCopyKeyList CFPreferencesCopyKeyList "/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"
+
+func cfPreferencesCopyKeyList_trampoline()
+
+func cfPreferencesCopyKeyList(applicationID, user, host CFRef) CFRef {
+	ret := syscall9(trampolinePC(cfPreferencesCopyKeyList_trampoline), uintptr(applicationID), uintptr(user), uintptr(host), 0, 0, 0, 0, 0, 0)
+	return CFRef(ret)
+}
@@ -4,117 +4,81 @@
 package cfpref
 
 import (
-	"fmt"
-	"path/filepath"
-	"unsafe"
-
-	"github.com/gemini/dnshield/internal/user"
+	"github.com/pkg/errors"
 )
 
-/*
-#cgo darwin CFLAGS: -DDARWIN -x objective-c
-#include <CoreFoundation/CoreFoundation.h>
-*/
-import "C"
-
-// FancyDefaults is a *very* rough pass at porting https://gist.github.com/gregneagle/010b369e86410a2f279ff8e980585c68
-func FancyDefaults(prefName, bundleID string) (string, interface{}) {
-	value := CFPreferencesCopyAppValue(prefName, bundleID)
-	return getConfigLevel(prefName, bundleID), value
-}
-
-func getPrefValue(key, domain, username string) interface{} {
-	keyCFString := cFStringRef(key)
-	defer C.CFRelease((C.CFTypeRef)(keyCFString))
-	domainCFString := cFStringRef(domain)
-	defer C.CFRelease((C.CFTypeRef)(domainCFString))
-	usernameCFString := cFStringRef(username)
-	defer C.CFRelease((C.CFTypeRef)(usernameCFString))
-
-	val := C.CFPreferencesCopyValue(
-		keyCFString, domainCFString, usernameCFString, C.kCFPreferencesAnyHost,
-	)
-	if C.CFTypeRef(val) != 0 {
-		// will panic if the is NULL
-		defer C.CFRelease((C.CFTypeRef)(val))
-	}
-	return goValueFromCFPlistRef(val)
-}
-
-// cFStringRef returns a C.CFStringRef which must be released with C.CFRelease.
-func cFStringRef(s string) C.CFStringRef {
-	return C.CFStringCreateWithCString(C.kCFAllocatorDefault, C.CString(s), C.kCFStringEncodingUTF8)
-}
-
-func goBoolean(ref C.CFBooleanRef) bool {
-	return ref == C.kCFBooleanTrue
-}
-
-func goInt(ref C.CFNumberRef) int {
-	var n int
-	numberType := C.CFNumberGetType(ref)
-	C.CFNumberGetValue(ref, numberType, unsafe.Pointer(&n))
-	return n
-}
-
-func goString(ref C.CFStringRef) string {
-	length := C.CFStringGetLength(ref)
-	if length == 0 {
-		// empty string
-		return ""
+// goValueFromCFPlistRef converts a CFPropertyListRef into its Go
+// equivalent: CFBoolean -> bool, CFNumber -> int or float64, CFString ->
+// string, CFData -> []byte, CFDate -> time.Time, CFArray -> []interface{},
+// and CFDictionary -> map[string]interface{}, recursing into the latter
+// two. MDM-pushed configuration profiles routinely nest all of these, so
+// an unrecognized type is reported back as an error rather than a panic -
+// callers should log it as a policy-load warning and move on rather than
+// crash the resolver.
+func goValueFromCFPlistRef(ref CFRef) (interface{}, error) {
+	if ref == 0 {
+		return "Unknown", nil
 	}
-	cfRange := C.CFRange{0, length}
-	enc := C.CFStringEncoding(C.kCFStringEncodingUTF8)
-	var usedBufLen C.CFIndex
-	if C.CFStringGetBytes(ref, cfRange, enc, 0, C.false, nil, 0, &usedBufLen) > 0 {
-		bytes := make([]byte, usedBufLen)
-		buffer := (*C.UInt8)(unsafe.Pointer(&bytes[0]))
-		if C.CFStringGetBytes(ref, cfRange, enc, 0, C.false, buffer, usedBufLen, nil) > 0 {
-			return *(*string)(unsafe.Pointer(&bytes))
+	switch typeID := cfGetTypeID(ref); typeID {
+	case cfBooleanGetTypeID():
+		return cfBooleanToBoolean(ref), nil
+	case cfNumberGetTypeID():
+		return cfNumberToValue(ref), nil
+	case cfStringGetTypeID():
+		return cfStringToString(ref), nil
+	case cfDataGetTypeID():
+		return cfDataToData(ref), nil
+	case cfDateGetTypeID():
+		return cfDateToTime(ref), nil
+	case cfArrayGetTypeID():
+		count := cfArrayGetCount(ref)
+		out := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			v, err := goValueFromCFPlistRef(cfArrayGetValueAtIndex(ref, i))
+			if err != nil {
+				return nil, errors.Wrapf(err, "element %d", i)
+			}
+			out = append(out, v)
 		}
-	}
-
-	return ""
-}
-
-func goValueFromCFPlistRef(ref C.CFPropertyListRef) interface{} {
-	if C.CFTypeRef(ref) == 0 {
-		return "Unknown"
-	}
-	switch typeID := C.CFGetTypeID(C.CFTypeRef(ref)); typeID {
-	case C.CFBooleanGetTypeID():
-		return goBoolean(C.CFBooleanRef(ref))
-	case C.CFNumberGetTypeID():
-		return goInt(C.CFNumberRef(ref))
-	case C.CFStringGetTypeID():
-		return goString(C.CFStringRef(ref))
+		return out, nil
+	case cfDictionaryGetTypeID():
+		keys, values := cfDictionaryGetKeysAndValues(ref)
+		out := make(map[string]interface{}, len(keys))
+		for i, k := range keys {
+			key := cfStringToString(k)
+			v, err := goValueFromCFPlistRef(values[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "key %q", key)
+			}
+			out[key] = v
+		}
+		return out, nil
 	default:
-		panic(fmt.Sprintf("unknown CF type id %v", typeID))
+		return nil, errors.Errorf("unknown CF type id %v", typeID)
 	}
 }
 
-func getConfigLevel(prefName, bundleID string) string {
-	if IsValueForced(prefName, bundleID) {
-		return "MANAGED"
-	}
-	user, err := user.GetConsoleUser()
-	if err != nil {
-		return "unknown"
-	}
-
-	homedir, _ := user.HomeDirectory()
-	levels := []string{
-		filepath.Join(homedir, "Library/Preferences/", bundleID+".plist"),
-		filepath.Join("/Library/Preferences", bundleID+".plist"),
-		filepath.Join("/var/root/Library/Preferences", bundleID+".plist"),
-	}
-
-	for _, level := range levels {
-		pv := getPrefValue(prefName, bundleID, user.UserName())
-		if pv != 0 {
-			return level
-		}
+// cfTypeName returns ref's CoreFoundation type as the same short name
+// cFPreferencesCopyAppValue has always returned alongside its decoded
+// value - string/boolean/data/number/date/array/dictionary, or Unknown
+// for anything goValueFromCFPlistRef doesn't recognize either.
+func cfTypeName(ref CFRef) string {
+	switch cfGetTypeID(ref) {
+	case cfStringGetTypeID():
+		return "string"
+	case cfBooleanGetTypeID():
+		return "boolean"
+	case cfDataGetTypeID():
+		return "data"
+	case cfNumberGetTypeID():
+		return "number"
+	case cfDateGetTypeID():
+		return "date"
+	case cfArrayGetTypeID():
+		return "array"
+	case cfDictionaryGetTypeID():
+		return "dictionary"
+	default:
+		return Unknown
 	}
-
-	return "unknown"
 }
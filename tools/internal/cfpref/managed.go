@@ -0,0 +1,118 @@
+//go:build darwin
+// +build darwin
+
+package cfpref
+
+// User/host sentinel values CFPreferencesCopyValue accepts in place of
+// an actual per-user/per-host name, mapped to CoreFoundation's special
+// kCFPreferencesAnyUser/CurrentUser/AnyHost/CurrentHost constants rather
+// than passed through as a literal string - CFPreferencesCopyValue tells
+// these apart from a real username/hostname by CFStringRef identity, not
+// by string content.
+const (
+	UserAny     = "kCFPreferencesAnyUser"
+	UserCurrent = "kCFPreferencesCurrentUser"
+	HostAny     = "kCFPreferencesAnyHost"
+	HostCurrent = "kCFPreferencesCurrentHost"
+)
+
+// cfUserRef resolves user to a CFStringRef, reporting whether the caller
+// owns (and must release) it: the sentinel constants above resolve to
+// CoreFoundation's own long-lived constants, anything else is a real
+// username turned into a new CFString.
+func cfUserRef(user string) (ref CFRef, owned bool) {
+	switch user {
+	case UserAny:
+		return kCFPreferencesAnyUser(), false
+	case UserCurrent:
+		return kCFPreferencesCurrentUser(), false
+	default:
+		return stringToCFString(user), true
+	}
+}
+
+// cfHostRef is cfUserRef's host-sentinel counterpart.
+func cfHostRef(host string) (ref CFRef, owned bool) {
+	switch host {
+	case HostAny:
+		return kCFPreferencesAnyHost(), false
+	case HostCurrent:
+		return kCFPreferencesCurrentHost(), false
+	default:
+		return stringToCFString(host), true
+	}
+}
+
+// CFPreferencesCopyValue returns domain's key preference as read for a
+// specific user/host pair, rather than CFPreferencesCopyAppValue's
+// merged search list - e.g. UserAny/HostCurrent for the system-wide
+// value an MDM configuration profile pushes, which the merged lookup
+// can't be relied on to distinguish from a value the console user set
+// themselves. user and host may each be one of the sentinel constants
+// above or a real per-user/per-host name.
+func CFPreferencesCopyValue(key, domain, user, host string) (interface{}, error) {
+	k := stringToCFString(key)
+	defer cfRelease(k)
+	d := stringToCFString(domain)
+	defer cfRelease(d)
+	u, ownsU := cfUserRef(user)
+	if ownsU {
+		defer cfRelease(u)
+	}
+	h, ownsH := cfHostRef(host)
+	if ownsH {
+		defer cfRelease(h)
+	}
+
+	val := cfPreferencesCopyValue(k, d, u, h)
+	if val != 0 {
+		defer cfRelease(val)
+	}
+	return goValueFromCFPlistRef(val)
+}
+
+// CFPreferencesAppValueIsForced reports whether domain's key is locked by
+// a configuration profile. It's IsValueForced exported under
+// CoreFoundation's own API name, for callers that expect this package's
+// naming to track the underlying C function it wraps.
+func CFPreferencesAppValueIsForced(key, domain string) bool {
+	return IsValueForced(key, domain)
+}
+
+// LoadManagedConfig returns domain's complete preference dictionary as
+// pushed by an MDM configuration profile (read from kCFPreferencesAnyUser/
+// kCFPreferencesCurrentHost, where profile-managed preferences land) and
+// whether any key in it is actually forced - i.e. whether dnshield should
+// treat the returned policy as a locked enterprise setting rather than a
+// default the end user could still override. It returns (nil, false) if
+// domain has no preferences at this user/host pair at all.
+func LoadManagedConfig(domain string) (map[string]interface{}, bool) {
+	d := stringToCFString(domain)
+	defer cfRelease(d)
+
+	keysRef := cfPreferencesCopyKeyList(d, kCFPreferencesAnyUser(), kCFPreferencesCurrentHost())
+	if keysRef == 0 {
+		return nil, false
+	}
+	defer cfRelease(keysRef)
+
+	count := cfArrayGetCount(keysRef)
+	if count == 0 {
+		return nil, false
+	}
+
+	config := make(map[string]interface{}, count)
+	managed := false
+	for i := 0; i < count; i++ {
+		key := cfStringToString(cfArrayGetValueAtIndex(keysRef, i))
+		value, err := CFPreferencesCopyValue(key, domain, UserAny, HostCurrent)
+		if err != nil {
+			continue
+		}
+		config[key] = value
+		if CFPreferencesAppValueIsForced(key, domain) {
+			managed = true
+		}
+	}
+	return config, managed
+}
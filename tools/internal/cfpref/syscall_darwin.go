@@ -0,0 +1,37 @@
+//go:build darwin
+// +build darwin
+
+package cfpref
+
+// syscall9 invokes the C function at fn with up to nine word-sized
+// arguments using the platform C calling convention, and returns its
+// single word-sized result. It is implemented in assembly per-arch in
+// syscall_darwin_{amd64,arm64}.s so that this package can call into
+// CoreFoundation without cgo: fn is the address of a trampoline that
+// simply jumps to a symbol resolved at link time via go:cgo_import_dynamic
+// (see corefoundation_darwin.go), mirroring the technique used by the Go
+// standard library's crypto/x509/internal/macos package.
+//
+// Unused trailing arguments must be passed as zero; the callee never
+// reads past the arity of the real function it was compiled against.
+//
+//go:noescape
+func syscall9(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9 uintptr) uintptr
+
+// syscall9f is syscall9 for C functions that return a double in the
+// platform's floating-point return register (e.g. CFDateGetAbsoluteTime)
+// rather than a general-purpose one.
+//
+//go:noescape
+func syscall9f(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9 uintptr) float64
+
+// syscallDateCreate calls a two-argument C function of the shape
+// CFDateCreate(CFAllocatorRef, CFAbsoluteTime) - its second argument is a
+// double, which the platform C calling convention passes in a
+// floating-point register entirely separate from a1..a9 above, so it
+// cannot be expressed as another syscall9 argument. This is a dedicated
+// trampoline for that one argument shape rather than a generic
+// mixed-register variant of syscall9.
+//
+//go:noescape
+func syscallDateCreate(fn, allocator uintptr, at float64) uintptr
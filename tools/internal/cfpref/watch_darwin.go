@@ -0,0 +1,103 @@
+//go:build darwin
+// +build darwin
+
+package cfpref
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gemini/dnshield/internal/trace"
+)
+
+// watchPollInterval is how often Watch re-reads bundleID's preference
+// domain looking for changes.
+const watchPollInterval = 2 * time.Second
+
+// Event describes an observed change to a single key in a watched
+// preference domain.
+type Event struct {
+	BundleID string
+	Key      string
+	Value    interface{}
+}
+
+// Watch reports changes to any key in bundleID's preference domain on the
+// returned channel, and returns a function to stop watching. The channel
+// is closed once the watch is stopped.
+//
+// CoreFoundation's native push mechanism for this
+// (kCFPreferencesDidChangeNotification via CFNotificationCenterAddObserver)
+// delivers the notification by invoking a C callback function pointer.
+// Producing a callback CoreFoundation can call back into Go requires
+// cgo's `//export` trampoline - there is no cgo-less equivalent for that
+// direction of call, unlike the outbound calls the rest of this package
+// makes - so Watch instead polls CFPreferencesCopyKeyList/CopyAppValue on
+// an interval and diffs against the previous snapshot. Callers see the
+// same external contract (an Event within roughly one poll interval of an
+// MDM push) without this package requiring a C compiler to build.
+func Watch(bundleID string) (<-chan Event, func(), error) {
+	events := make(chan Event, 8)
+	stop := make(chan struct{})
+
+	go watchLoop(bundleID, events, stop)
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(stop) })
+	}
+	return events, cancel, nil
+}
+
+func watchLoop(bundleID string, events chan<- Event, stop <-chan struct{}) {
+	defer close(events)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	last := snapshotAppValues(bundleID)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			current := snapshotAppValues(bundleID)
+			for key, value := range current {
+				if prev, ok := last[key]; !ok || !reflect.DeepEqual(prev, value) {
+					trace.Logger("cfpref").Debug().
+						Str("bundle_id", bundleID).
+						Str("key", key).
+						Msg("preference value changed")
+					select {
+					case events <- Event{BundleID: bundleID, Key: key, Value: value}:
+					case <-stop:
+						return
+					}
+				}
+			}
+			last = current
+		}
+	}
+}
+
+// snapshotAppValues returns the current value of every key set in
+// bundleID's preference domain for the current user.
+func snapshotAppValues(bundleID string) map[string]interface{} {
+	domain := stringToCFString(bundleID)
+	defer cfRelease(domain)
+
+	keysRef := cfPreferencesCopyKeyList(domain, kCFPreferencesCurrentUser(), kCFPreferencesAnyHost())
+	if keysRef == 0 {
+		return nil
+	}
+	defer cfRelease(keysRef)
+
+	count := cfArrayGetCount(keysRef)
+	out := make(map[string]interface{}, count)
+	for i := 0; i < count; i++ {
+		key := cfStringToString(cfArrayGetValueAtIndex(keysRef, i))
+		out[key] = CFPreferencesCopyAppValue(key, bundleID)
+	}
+	return out
+}
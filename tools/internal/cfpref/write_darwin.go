@@ -0,0 +1,125 @@
+//go:build darwin
+// +build darwin
+
+package cfpref
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CFPreferencesSetAppValue writes value to the given preference key in
+// bundleID's domain and synchronizes it to disk immediately, under
+// CoreFoundation's own API name (see CFPreferencesCopyValue in managed.go
+// for the same naming convention). It supports the same shape of values
+// goValueFromCFPlistRef produces on read: bool, string, int/int64,
+// float64, []byte, time.Time, []interface{}, and map[string]interface{}
+// (recursively).
+//
+// Like the underlying CFPreferencesSetValue, a write is silently
+// discarded by cfprefsd unless the calling process is running as the
+// target user (or root, for the system-wide domain) - there is no error
+// return for "wrong user", it just doesn't take effect. Callers that need
+// to confirm a write landed should read it back.
+func CFPreferencesSetAppValue(prefName, bundleID string, value interface{}) error {
+	var created []CFRef
+	defer func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			cfRelease(created[i])
+		}
+	}()
+
+	cfValue, err := goValueToCFPlistRef(value, &created)
+	if err != nil {
+		return errors.Wrapf(err, "converting %q", prefName)
+	}
+
+	key := stringToCFString(prefName)
+	created = append(created, key)
+	domain := stringToCFString(bundleID)
+	created = append(created, domain)
+
+	cfPreferencesSetAppValue(key, cfValue, domain)
+	return CFPreferencesAppSynchronize(bundleID)
+}
+
+// Delete removes prefName from bundleID's domain - CFPreferencesSetValue's
+// documented behavior when passed a NULL value - and synchronizes the
+// removal to disk immediately. Subject to the same per-user write
+// restriction as CFPreferencesSetAppValue.
+func Delete(prefName, bundleID string) error {
+	key := stringToCFString(prefName)
+	defer cfRelease(key)
+	domain := stringToCFString(bundleID)
+	defer cfRelease(domain)
+
+	cfPreferencesSetAppValue(key, 0, domain)
+	return CFPreferencesAppSynchronize(bundleID)
+}
+
+// CFPreferencesAppSynchronize flushes bundleID's pending preference
+// writes to disk. CFPreferencesSetAppValue and Delete already call this
+// themselves; it's exported separately for callers that batch several
+// writes and want to synchronize once at the end.
+func CFPreferencesAppSynchronize(bundleID string) error {
+	domain := stringToCFString(bundleID)
+	defer cfRelease(domain)
+
+	if !cfPreferencesAppSynchronize(domain) {
+		return errors.Errorf("CFPreferencesAppSynchronize failed for %q", bundleID)
+	}
+	return nil
+}
+
+// goValueToCFPlistRef is the write-side counterpart of
+// goValueFromCFPlistRef. Every CFRef it allocates (including ones nested
+// inside arrays/dictionaries) is appended to *created so the caller can
+// release the whole tree in one pass once CoreFoundation is done reading
+// it.
+func goValueToCFPlistRef(value interface{}, created *[]CFRef) (CFRef, error) {
+	track := func(ref CFRef) CFRef {
+		*created = append(*created, ref)
+		return ref
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return cfBooleanFromBool(v), nil
+	case string:
+		return track(stringToCFString(v)), nil
+	case int:
+		return track(cfNumberCreateInt(int64(v))), nil
+	case int64:
+		return track(cfNumberCreateInt(v)), nil
+	case float64:
+		return track(cfNumberCreateFloat(v)), nil
+	case []byte:
+		return track(cfDataCreate(v)), nil
+	case time.Time:
+		return track(cfDateCreate(v)), nil
+	case []interface{}:
+		arr := track(cfArrayCreateMutable())
+		for i, elem := range v {
+			cfElem, err := goValueToCFPlistRef(elem, created)
+			if err != nil {
+				return 0, errors.Wrapf(err, "element %d", i)
+			}
+			cfArrayAppendValue(arr, cfElem)
+		}
+		return arr, nil
+	case map[string]interface{}:
+		dict := track(cfDictionaryCreateMutable())
+		for key, elem := range v {
+			cfKey := track(stringToCFString(key))
+			cfElem, err := goValueToCFPlistRef(elem, created)
+			if err != nil {
+				return 0, errors.Wrapf(err, "key %q", key)
+			}
+			cfDictionarySetValue(dict, cfKey, cfElem)
+		}
+		return dict, nil
+	default:
+		return 0, errors.Errorf("unsupported value type %T", value)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build darwin
+// +build darwin
+
+package fsmonitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// kqueueBackend is the darwin Backend, backed directly by kqueue. It
+// watches dir's own fd for NOTE_WRITE/NOTE_DELETE/NOTE_RENAME - the
+// directory-level events a child being created, removed, or atomically
+// replaced produces - and, whenever target exists, additionally watches
+// target's own fd for NOTE_WRITE/NOTE_EXTEND/NOTE_ATTRIB, since kqueue's
+// directory-level watch doesn't report a child's in-place content writes
+// the way inotify's does.
+type kqueueBackend struct{}
+
+func defaultBackend() Backend {
+	return kqueueBackend{}
+}
+
+func (kqueueBackend) Watch(ctx context.Context, dir, target string, events chan<- Event) error {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return fmt.Errorf("create kqueue: %w", err)
+	}
+	defer syscall.Close(kq)
+
+	dirFd, err := syscall.Open(dir, syscall.O_EVTONLY, 0)
+	if err != nil {
+		return fmt.Errorf("open directory %s: %w", dir, err)
+	}
+	defer syscall.Close(dirFd)
+
+	if err := registerVnodeEvent(kq, dirFd, syscall.NOTE_WRITE|syscall.NOTE_DELETE|syscall.NOTE_RENAME); err != nil {
+		return fmt.Errorf("register directory kevent: %w", err)
+	}
+
+	fileFd := -1
+	defer func() {
+		if fileFd >= 0 {
+			syscall.Close(fileFd)
+		}
+	}()
+	openTarget := func() bool {
+		fd, openErr := syscall.Open(target, syscall.O_EVTONLY, 0)
+		if openErr != nil {
+			return false
+		}
+		if regErr := registerVnodeEvent(kq, fd, syscall.NOTE_WRITE|syscall.NOTE_EXTEND|syscall.NOTE_ATTRIB|syscall.NOTE_DELETE|syscall.NOTE_RENAME); regErr != nil {
+			syscall.Close(fd)
+			return false
+		}
+		fileFd = fd
+		return true
+	}
+	openTarget()
+
+	kevents := make([]syscall.Kevent_t, 2)
+	timeout := syscall.NsecToTimespec(int64(500 * time.Millisecond))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		n, waitErr := syscall.Kevent(kq, nil, kevents, &timeout)
+		if waitErr != nil {
+			if errors.Is(waitErr, syscall.EINTR) {
+				continue
+			}
+			return fmt.Errorf("kevent wait: %w", waitErr)
+		}
+		if n == 0 {
+			continue
+		}
+
+		for _, ev := range kevents[:n] {
+			switch int(ev.Ident) {
+			case dirFd:
+				if fileFd < 0 && openTarget() {
+					events <- Event{Path: target, Op: OpCreate}
+				}
+			case fileFd:
+				if ev.Fflags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME) != 0 {
+					syscall.Close(fileFd)
+					fileFd = -1
+					events <- Event{Path: target, Op: OpRemove}
+					continue
+				}
+				if ev.Fflags&(syscall.NOTE_WRITE|syscall.NOTE_EXTEND|syscall.NOTE_ATTRIB) != 0 {
+					events <- Event{Path: target, Op: OpWrite}
+				}
+			}
+		}
+	}
+}
+
+func registerVnodeEvent(kq, fd int, fflags uint32) error {
+	ev := syscall.Kevent_t{
+		Ident:  uint64(fd),
+		Filter: syscall.EVFILT_VNODE,
+		Flags:  syscall.EV_ADD | syscall.EV_CLEAR,
+		Fflags: fflags,
+	}
+	_, err := syscall.Kevent(kq, []syscall.Kevent_t{ev}, nil, nil)
+	return err
+}
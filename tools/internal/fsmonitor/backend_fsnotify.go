@@ -0,0 +1,70 @@
+//go:build !darwin
+// +build !darwin
+
+package fsmonitor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsnotifyBackend is the Backend used on every non-darwin platform
+// dnshield ships on. It delegates to fsnotify, which is itself backed by
+// inotify on Linux and ReadDirectoryChangesW on Windows, so this one
+// small adapter covers both without duplicating either platform's
+// syscalls.
+type fsnotifyBackend struct{}
+
+func defaultBackend() Backend {
+	return fsnotifyBackend{}
+}
+
+func (fsnotifyBackend) Watch(ctx context.Context, dir, target string, events chan<- Event) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create fsnotify watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("fsnotify watcher closed")
+			}
+			if filepath.Clean(event.Name) != target {
+				continue
+			}
+			events <- Event{Path: target, Op: translateOp(event.Op)}
+
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("fsnotify watcher closed")
+			}
+			return fmt.Errorf("fsnotify watcher error: %w", watchErr)
+		}
+	}
+}
+
+func translateOp(op fsnotify.Op) Op {
+	switch {
+	case op.Has(fsnotify.Remove):
+		return OpRemove
+	case op.Has(fsnotify.Rename):
+		return OpRename
+	case op.Has(fsnotify.Create):
+		return OpCreate
+	default:
+		return OpWrite
+	}
+}
@@ -0,0 +1,180 @@
+// Package fsmonitor provides a single, reusable file-change watcher with
+// pluggable OS backends (kqueue on darwin, fsnotify - inotify on Linux,
+// ReadDirectoryChangesW on Windows - everywhere else, and a polling
+// fallback), so each consumer that cares about a file changing doesn't
+// need to roll its own platform-specific watch loop.
+//
+// A Monitor watches the directory containing its target file, not the
+// file itself, so it survives the file being replaced wholesale via the
+// common write-temp-then-rename pattern, and debounces rapid-fire events
+// (e.g. the several writes a text editor's "save" produces) into a
+// single notification. Multiple independent subscribers can share one
+// Monitor via Subscribe.
+package fsmonitor
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Op describes the kind of change a backend observed.
+type Op uint8
+
+const (
+	OpCreate Op = 1 << iota
+	OpWrite
+	OpRemove
+	OpRename
+)
+
+// Event reports a change to the file a Monitor is watching.
+type Event struct {
+	Path string
+	Op   Op
+}
+
+// Backend is a pluggable file-change notification mechanism. Watch
+// blocks, delivering an Event on events each time target - a single file
+// within dir - is created, written, removed, or renamed, until ctx is
+// canceled or the backend fails outright (at which point Monitor falls
+// back to polling).
+type Backend interface {
+	Watch(ctx context.Context, dir, target string, events chan<- Event) error
+}
+
+// DefaultDebounce coalesces the handful of events a single logical change
+// tends to produce into one notification.
+const DefaultDebounce = 200 * time.Millisecond
+
+// pollFallbackInterval is how often Monitor re-stats its target if no
+// native backend is available, or the native backend fails outright.
+const pollFallbackInterval = 2 * time.Second
+
+// Monitor watches path and fans a debounced notification out to any
+// number of subscribers, so independent consumers (the hosts-file
+// sanitizer, the rules database reloader, and so on) can share one watch
+// instead of each rolling their own polling loop.
+type Monitor struct {
+	path     string
+	dir      string
+	backend  Backend
+	debounce time.Duration
+
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+	errs chan error
+}
+
+// New returns a Monitor for path using backend, coalescing rapid events
+// within debounce into a single notification. Call Start to begin
+// watching.
+func New(path string, backend Backend, debounce time.Duration) *Monitor {
+	return &Monitor{
+		path:     path,
+		dir:      filepath.Dir(path),
+		backend:  backend,
+		debounce: debounce,
+		subs:     make(map[chan Event]struct{}),
+		errs:     make(chan error, 1),
+	}
+}
+
+// NewDefault returns a Monitor for path using the platform's native
+// backend, debouncing within DefaultDebounce.
+func NewDefault(path string) *Monitor {
+	return New(path, defaultBackend(), DefaultDebounce)
+}
+
+// Subscribe returns a channel delivering every debounced event for path,
+// and a function to unsubscribe and release it. Safe to call from
+// multiple goroutines, including after Start.
+func (m *Monitor) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 1)
+	m.mu.Lock()
+	m.subs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subs, ch)
+			m.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Errors reports failures from the native backend (e.g. it couldn't
+// start, so Monitor fell back to polling).
+func (m *Monitor) Errors() <-chan error {
+	return m.errs
+}
+
+// Start begins watching in a background goroutine.
+func (m *Monitor) Start(ctx context.Context) {
+	go m.run(ctx)
+}
+
+func (m *Monitor) run(ctx context.Context) {
+	raw := make(chan Event, 8)
+	go func() {
+		defer close(raw)
+		if err := m.backend.Watch(ctx, m.dir, m.path, raw); err != nil && ctx.Err() == nil {
+			select {
+			case m.errs <- err:
+			default:
+			}
+			// Native backend failed outright (e.g. inotify's watch
+			// descriptor limit exhausted); polling is always available and
+			// never fails, so this is the end of the fallback chain.
+			_ = pollingBackend{interval: pollFallbackInterval}.Watch(ctx, m.dir, m.path, raw)
+		}
+	}()
+	m.debounceLoop(ctx, raw)
+}
+
+func (m *Monitor) debounceLoop(ctx context.Context, raw <-chan Event) {
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	var pending Event
+	pendingSet := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-raw:
+			if !ok {
+				return
+			}
+			pending = e
+			pendingSet = true
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(m.debounce)
+			timerC = timer.C
+		case <-timerC:
+			if pendingSet {
+				m.broadcast(pending)
+				pendingSet = false
+			}
+			timerC = nil
+		}
+	}
+}
+
+func (m *Monitor) broadcast(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range m.subs {
+		select {
+		case ch <- e:
+		default: // slow subscriber; drop rather than block every other one
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package fsmonitor
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// pollingBackend has no native OS dependency, so it's always available as
+// the final fallback Monitor drops back to if its preferred backend
+// fails to start.
+type pollingBackend struct {
+	interval time.Duration
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statState(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+func (p pollingBackend) Watch(ctx context.Context, dir, target string, events chan<- Event) error {
+	interval := p.interval
+	if interval <= 0 {
+		interval = pollFallbackInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := statState(target)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			cur := statState(target)
+			switch {
+			case cur.exists && !last.exists:
+				events <- Event{Path: target, Op: OpCreate}
+			case !cur.exists && last.exists:
+				events <- Event{Path: target, Op: OpRemove}
+			case cur.exists && (cur.size != last.size || !cur.modTime.Equal(last.modTime)):
+				events <- Event{Path: target, Op: OpWrite}
+			}
+			last = cur
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package hoststats collects lightweight host-health context - load
+// averages, uptime, logged-in user count, CPU count, and free memory -
+// so callers can attach it to security events. Correlating a bypass
+// attempt with a spike in logged-in users or CPU load is one way to tell
+// attacker activity apart from a scripted CI host doing the same thing.
+package hoststats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// Sample is a point-in-time snapshot of host health.
+type Sample struct {
+	Load1           float64 `json:"load1"`
+	Load5           float64 `json:"load5"`
+	Load15          float64 `json:"load15"`
+	UptimeSeconds   uint64  `json:"uptime_seconds"`
+	LoggedInUsers   int     `json:"logged_in_users"`
+	CPUCount        int     `json:"cpu_count"`
+	FreeMemoryBytes uint64  `json:"free_memory_bytes"`
+}
+
+// Collector caches the most recent Sample, refreshing it at most once
+// per ttl so a burst of events doesn't hammer the OS for load/memory
+// stats on every single one.
+type Collector struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	last      Sample
+	lastFetch time.Time
+	fetched   bool
+}
+
+// NewCollector returns a Collector that refreshes its cached Sample at
+// most once every ttl.
+func NewCollector(ttl time.Duration) *Collector {
+	return &Collector{ttl: ttl}
+}
+
+// Sample returns the cached host-health snapshot, collecting a fresh one
+// first if ttl has elapsed since the last collection.
+func (c *Collector) Sample() Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fetched && time.Since(c.lastFetch) < c.ttl {
+		return c.last
+	}
+
+	c.last = collect()
+	c.lastFetch = time.Now()
+	c.fetched = true
+	return c.last
+}
+
+// collect gathers a fresh Sample. Each metric is best-effort: a failure
+// reading one (e.g. on a platform gopsutil doesn't fully support) leaves
+// it at its zero value rather than discarding the rest.
+func collect() Sample {
+	var s Sample
+
+	if avg, err := load.Avg(); err == nil {
+		s.Load1 = avg.Load1
+		s.Load5 = avg.Load5
+		s.Load15 = avg.Load15
+	}
+
+	if uptime, err := host.Uptime(); err == nil {
+		s.UptimeSeconds = uptime
+	}
+
+	if users, err := host.Users(); err == nil {
+		s.LoggedInUsers = len(users)
+	}
+
+	if count, err := cpu.Counts(true); err == nil {
+		s.CPUCount = count
+	}
+
+	if vm, err := mem.VirtualMemory(); err == nil {
+		s.FreeMemoryBytes = vm.Available
+	}
+
+	return s
+}
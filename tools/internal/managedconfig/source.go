@@ -0,0 +1,64 @@
+// Package managedconfig gives dnshield a single, cross-platform way to
+// read enterprise-managed configuration: macOS configuration profiles,
+// Windows Group Policy registry keys, and layered YAML config files on
+// Linux. Callers in the resolver/policy layer take a Source instead of
+// importing a platform package (like internal/cfpref) directly.
+package managedconfig
+
+import (
+	"context"
+	"time"
+)
+
+// watchPollInterval is how often a Source that has no native push
+// notification mechanism re-checks its backing store for changes.
+const watchPollInterval = 2 * time.Second
+
+// Level indicates where a managed configuration value was set from,
+// ordered loosely from least to most authoritative.
+type Level int
+
+const (
+	// LevelUnset means the key has no value in this source.
+	LevelUnset Level = iota
+	// LevelUser means the value came from the current user's own config.
+	LevelUser
+	// LevelHost means the value came from a machine-wide (but not
+	// centrally managed) config location.
+	LevelHost
+	// LevelManaged means the value is enforced by MDM/group policy and
+	// must not be overridden.
+	LevelManaged
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelUser:
+		return "user"
+	case LevelHost:
+		return "host"
+	case LevelManaged:
+		return "managed"
+	default:
+		return "unset"
+	}
+}
+
+// Event reports that a key's value changed in a Source's backing store.
+type Event struct {
+	Key   string
+	Value interface{}
+}
+
+// Source is a platform-specific managed configuration backend.
+type Source interface {
+	// Value returns the current value of key and the level it was set
+	// at. An unset key returns (nil, LevelUnset, nil).
+	Value(key string) (val interface{}, level Level, err error)
+	// IsForced reports whether key is locked down by managed policy and
+	// must not be overridden by the user or the app itself.
+	IsForced(key string) bool
+	// Watch reports changes to any key in this source until ctx is
+	// done, at which point the returned channel is closed.
+	Watch(ctx context.Context) (<-chan Event, error)
+}
@@ -0,0 +1,73 @@
+//go:build darwin
+// +build darwin
+
+package managedconfig
+
+import (
+	"context"
+
+	"github.com/gemini/dnshield/internal/cfpref"
+)
+
+// New returns a Source backed by CFPreferences for the given preference
+// domain (normally the app's bundle ID).
+func New(domain string) Source {
+	return &darwinSource{domain: domain}
+}
+
+type darwinSource struct {
+	domain string
+}
+
+func (s *darwinSource) Value(key string) (interface{}, Level, error) {
+	value := cfpref.CFPreferencesCopyAppValue(key, s.domain)
+	if value == nil {
+		return nil, LevelUnset, nil
+	}
+	return value, darwinLevel(key, s.domain), nil
+}
+
+func (s *darwinSource) IsForced(key string) bool {
+	return cfpref.IsValueForced(key, s.domain)
+}
+
+func (s *darwinSource) Watch(ctx context.Context) (<-chan Event, error) {
+	cfEvents, cancel, err := cfpref.Watch(s.domain)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-cfEvents:
+				if !ok {
+					return
+				}
+				select {
+				case out <- Event{Key: e.Key, Value: e.Value}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// darwinLevel reports the level key is set at in domain. cfpref doesn't
+// distinguish which of the user/host/managed plist paths actually matched a
+// forced value, so this collapses to the two distinctions dnshield's callers
+// currently act on: MANAGED (locked down by a configuration profile, must
+// not be overridden) versus everything else (treated as LevelUser).
+func darwinLevel(key, domain string) Level {
+	if cfpref.IsValueForced(key, domain) {
+		return LevelManaged
+	}
+	return LevelUser
+}
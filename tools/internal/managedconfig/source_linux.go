@@ -0,0 +1,179 @@
+//go:build linux
+// +build linux
+
+package managedconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// managedConfigDir holds admin-managed YAML fragments that take
+// precedence over the user's own config, mirroring the role macOS
+// configuration profiles and Windows group policy play on those
+// platforms.
+const managedConfigDir = "/etc/dnshield/managed.d"
+
+// New returns a Source backed by YAML config files: any *.yaml fragment
+// under /etc/dnshield/managed.d layers over (and wins against)
+// $XDG_CONFIG_HOME/dnshield/config.yaml.
+func New(appName string) Source {
+	return &linuxSource{appName: appName}
+}
+
+type linuxSource struct {
+	appName string
+}
+
+func (s *linuxSource) Value(key string) (interface{}, Level, error) {
+	managed, err := loadManagedConfig()
+	if err != nil {
+		return nil, LevelUnset, err
+	}
+	if v, ok := managed[key]; ok {
+		return v, LevelManaged, nil
+	}
+
+	user, err := loadUserConfig(s.appName)
+	if err != nil {
+		return nil, LevelUnset, err
+	}
+	if v, ok := user[key]; ok {
+		return v, LevelUser, nil
+	}
+
+	return nil, LevelUnset, nil
+}
+
+func (s *linuxSource) IsForced(key string) bool {
+	managed, err := loadManagedConfig()
+	if err != nil {
+		return false
+	}
+	_, ok := managed[key]
+	return ok
+}
+
+func (s *linuxSource) Watch(ctx context.Context) (<-chan Event, error) {
+	out := make(chan Event)
+	go s.watchLoop(ctx, out)
+	return out, nil
+}
+
+func (s *linuxSource) watchLoop(ctx context.Context, events chan<- Event) {
+	defer close(events)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	last := s.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := s.snapshot()
+			for key, value := range current {
+				if prev, ok := last[key]; !ok || !reflect.DeepEqual(prev, value) {
+					select {
+					case events <- Event{Key: key, Value: value}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			last = current
+		}
+	}
+}
+
+func (s *linuxSource) snapshot() map[string]interface{} {
+	out := make(map[string]interface{})
+	if user, err := loadUserConfig(s.appName); err == nil {
+		for k, v := range user {
+			out[k] = v
+		}
+	}
+	if managed, err := loadManagedConfig(); err == nil {
+		for k, v := range managed {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// loadManagedConfig merges every *.yaml fragment under managedConfigDir,
+// in filename order, later files winning on key conflicts. A missing
+// directory is not an error - most machines aren't enrolled in MDM.
+func loadManagedConfig() (map[string]interface{}, error) {
+	entries, err := os.ReadDir(managedConfigDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading managed config directory")
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".yaml" {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	merged := make(map[string]interface{})
+	for _, name := range names {
+		fragment, err := loadYAMLFile(filepath.Join(managedConfigDir, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading %s", name)
+		}
+		for k, v := range fragment {
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
+
+// loadUserConfig reads $XDG_CONFIG_HOME/<appName>/config.yaml, falling
+// back to ~/.config/<appName>/config.yaml. A missing file is not an
+// error - it just means the user hasn't configured anything locally.
+func loadUserConfig(appName string) (map[string]interface{}, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "resolving home directory")
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+
+	config, err := loadYAMLFile(filepath.Join(configHome, appName, "config.yaml"))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+func loadYAMLFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	return out, nil
+}
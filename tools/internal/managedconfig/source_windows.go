@@ -0,0 +1,135 @@
+//go:build windows
+// +build windows
+
+package managedconfig
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// New returns a Source backed by the Windows registry: HKLM\SOFTWARE\Policies\<appName>
+// holds group-policy-managed values, HKCU\SOFTWARE\<appName> holds the
+// user's own.
+func New(appName string) Source {
+	return &windowsSource{
+		managedKey: `SOFTWARE\Policies\` + appName,
+		userKey:    `SOFTWARE\` + appName,
+	}
+}
+
+type windowsSource struct {
+	managedKey string
+	userKey    string
+}
+
+func (s *windowsSource) Value(key string) (interface{}, Level, error) {
+	if v, ok, err := readRegistryValue(registry.LOCAL_MACHINE, s.managedKey, key); err != nil {
+		return nil, LevelUnset, err
+	} else if ok {
+		return v, LevelManaged, nil
+	}
+
+	if v, ok, err := readRegistryValue(registry.CURRENT_USER, s.userKey, key); err != nil {
+		return nil, LevelUnset, err
+	} else if ok {
+		return v, LevelUser, nil
+	}
+
+	return nil, LevelUnset, nil
+}
+
+func (s *windowsSource) IsForced(key string) bool {
+	_, ok, err := readRegistryValue(registry.LOCAL_MACHINE, s.managedKey, key)
+	return err == nil && ok
+}
+
+// readRegistryValue reads name out of root\path, trying the value types
+// policy-managed settings are normally shipped as: string (REG_SZ/REG_EXPAND_SZ)
+// then DWORD (REG_DWORD). A missing key or value is reported as (nil,
+// false, nil) rather than an error, since that's the common case for a
+// key nobody has configured.
+func readRegistryValue(root registry.Key, path, name string) (interface{}, bool, error) {
+	k, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+	if err != nil {
+		if err == registry.ErrNotExist {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer k.Close()
+
+	if s, _, err := k.GetStringValue(name); err == nil {
+		return s, true, nil
+	}
+	if i, _, err := k.GetIntegerValue(name); err == nil {
+		return int64(i), true, nil
+	}
+	if err == registry.ErrNotExist {
+		return nil, false, nil
+	}
+	return nil, false, err
+}
+
+func (s *windowsSource) Watch(ctx context.Context) (<-chan Event, error) {
+	out := make(chan Event)
+	go s.watchLoop(ctx, out)
+	return out, nil
+}
+
+func (s *windowsSource) watchLoop(ctx context.Context, events chan<- Event) {
+	defer close(events)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	last := s.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := s.snapshot()
+			for key, value := range current {
+				if prev, ok := last[key]; !ok || !reflect.DeepEqual(prev, value) {
+					select {
+					case events <- Event{Key: key, Value: value}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			last = current
+		}
+	}
+}
+
+// snapshot reads every value set under the managed and user keys so
+// watchLoop has something to diff against on the next poll.
+func (s *windowsSource) snapshot() map[string]interface{} {
+	out := make(map[string]interface{})
+	s.snapshotInto(registry.LOCAL_MACHINE, s.managedKey, out)
+	s.snapshotInto(registry.CURRENT_USER, s.userKey, out)
+	return out
+}
+
+func (s *windowsSource) snapshotInto(root registry.Key, path string, out map[string]interface{}) {
+	k, err := registry.OpenKey(root, path, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(-1)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		if v, ok, err := readRegistryValue(root, path, name); err == nil && ok {
+			out[name] = v
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package authz resolves which manifest-editor actions a logged-in
+// GitHub user is allowed to take, based on a roles.yaml checked into the
+// target repository.
+package authz
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Scope is a single permission string, e.g. "manifest:read",
+// "manifest:write:team/platform", or "pr:create". A trailing "*"
+// segment matches any value in that position.
+type Scope string
+
+const (
+	// ScopeManifestRead allows viewing manifests and domains.
+	ScopeManifestRead Scope = "manifest:read"
+	// ScopePRCreate allows opening pull requests for manifest changes.
+	ScopePRCreate Scope = "pr:create"
+	// ScopeAuditRead allows reading the mutation audit log.
+	ScopeAuditRead Scope = "audit:read"
+)
+
+// ScopeManifestWriteTeam is the scope required to edit
+// includes/team/<slug>.json.
+func ScopeManifestWriteTeam(slug string) Scope {
+	return Scope("manifest:write:team/" + slug)
+}
+
+// ScopeManifestWriteGroup is the scope required to edit
+// includes/group/<name>.json.
+func ScopeManifestWriteGroup(name string) Scope {
+	return Scope("manifest:write:group/" + name)
+}
+
+// ScopeManifestWriteMachine is the scope required to edit a per-machine
+// manifest under machines/.
+func ScopeManifestWriteMachine(name string) Scope {
+	return Scope("manifest:write:machine/" + name)
+}
+
+// ScopeManifestWriteAny is the broad write scope required to `git push`
+// to the manifests repo, since a single push can touch any file in the
+// tree rather than one resource the way the editor's handlers do.
+const ScopeManifestWriteAny Scope = "manifest:write:*"
+
+// ScopeForManifestPath maps a manifest-editor file path (as used by
+// handleManifests/handleDomains/handlePullRequest, e.g.
+// "includes/team/platform.json" or "machines/C02ABC1234.json") to the
+// write scope required to modify it.
+func ScopeForManifestPath(path string) Scope {
+	path = strings.TrimSuffix(path, ".json")
+	path = strings.TrimPrefix(path, "includes/")
+
+	switch {
+	case strings.HasPrefix(path, "team/"):
+		return ScopeManifestWriteTeam(strings.TrimPrefix(path, "team/"))
+	case strings.HasPrefix(path, "group/"):
+		return ScopeManifestWriteGroup(strings.TrimPrefix(path, "group/"))
+	case strings.HasPrefix(path, "machines/"):
+		return ScopeManifestWriteMachine(strings.TrimPrefix(path, "machines/"))
+	default:
+		// Global manifests (global-allowlist, global-blocklist, and
+		// anything else outside team/group/machine) get their own
+		// group-shaped scope named after the file itself, e.g.
+		// "manifest:write:group/global-blocklist" - still a distinct,
+		// narrow scope per file, not ScopeManifestWriteAny. A role needs
+		// to be assigned that specific scope (or the "*" wildcard) to
+		// write one of these.
+		return ScopeManifestWriteGroup(path)
+	}
+}
+
+// Allows reports whether scopes grants required, honoring a trailing
+// "*" segment in a held scope as a wildcard for the rest of required.
+func Allows(scopes []Scope, required Scope) bool {
+	for _, held := range scopes {
+		if scopeMatches(held, required) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeMatches(held, required Scope) bool {
+	if held == required {
+		return true
+	}
+	h, r := string(held), string(required)
+	if strings.HasSuffix(h, "*") {
+		prefix := strings.TrimSuffix(h, "*")
+		return strings.HasPrefix(r, prefix)
+	}
+	return false
+}
+
+// Config is the parsed roles.yaml: a set of named roles (each a list of
+// scopes) and assignments of those roles to GitHub logins or teams.
+type Config struct {
+	Roles       map[string][]Scope `yaml:"roles"`
+	Assignments struct {
+		// Users maps a GitHub login to a role name.
+		Users map[string]string `yaml:"users"`
+		// Teams maps a "org/team-slug" GitHub team to a role name; it
+		// applies to any user who is a member of that team.
+		Teams map[string]string `yaml:"teams"`
+	} `yaml:"assignments"`
+}
+
+// Load reads and parses roles.yaml from path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading roles.yaml")
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrap(err, "parsing roles.yaml")
+	}
+	return &cfg, nil
+}
+
+// ScopesForUser resolves the scopes granted to login, who is a member of
+// teams (each formatted "org/team-slug"). A user can hold scopes both
+// through a direct login assignment and through any of their teams; the
+// union of all of them applies.
+func (c *Config) ScopesForUser(login string, teams []string) []Scope {
+	var scopes []Scope
+
+	addRole := func(role string) {
+		scopes = append(scopes, c.Roles[role]...)
+	}
+
+	if role, ok := c.Assignments.Users[login]; ok {
+		addRole(role)
+	}
+	for _, team := range teams {
+		if role, ok := c.Assignments.Teams[team]; ok {
+			addRole(role)
+		}
+	}
+
+	return scopes
+}
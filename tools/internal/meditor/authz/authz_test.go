@@ -0,0 +1,74 @@
+package authz
+
+import "testing"
+
+func TestScopeForManifestPath(t *testing.T) {
+	cases := []struct {
+		path string
+		want Scope
+	}{
+		{"includes/team/platform.json", ScopeManifestWriteTeam("platform")},
+		{"includes/group/engineering.json", ScopeManifestWriteGroup("engineering")},
+		{"includes/machines/C02ABC1234.json", ScopeManifestWriteMachine("C02ABC1234")},
+		{"includes/global-blocklist.json", ScopeManifestWriteGroup("global-blocklist")},
+	}
+	for _, c := range cases {
+		if got := ScopeForManifestPath(c.path); got != c.want {
+			t.Errorf("ScopeForManifestPath(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		name     string
+		held     []Scope
+		required Scope
+		want     bool
+	}{
+		{"exact match", []Scope{ScopeManifestRead}, ScopeManifestRead, true},
+		{"no match", []Scope{ScopeManifestRead}, ScopePRCreate, false},
+		{"wildcard matches any team", []Scope{ScopeManifestWriteAny}, ScopeManifestWriteTeam("platform"), true},
+		{"wildcard doesn't match unrelated scope", []Scope{ScopeManifestWriteAny}, ScopeAuditRead, false},
+		{"scoped wildcard matches only its prefix", []Scope{Scope("manifest:write:team/*")}, ScopeManifestWriteTeam("platform"), true},
+		{"scoped wildcard rejects other resource", []Scope{Scope("manifest:write:team/*")}, ScopeManifestWriteGroup("platform"), false},
+		{"empty held scopes", nil, ScopeManifestRead, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Allows(c.held, c.required); got != c.want {
+				t.Errorf("Allows(%v, %q) = %v, want %v", c.held, c.required, got, c.want)
+			}
+		})
+	}
+}
+
+func TestConfigScopesForUser(t *testing.T) {
+	cfg := &Config{
+		Roles: map[string][]Scope{
+			"admin":  {ScopeManifestWriteAny, ScopeAuditRead},
+			"editor": {ScopeManifestWriteTeam("platform")},
+		},
+	}
+	cfg.Assignments.Users = map[string]string{"alice": "admin"}
+	cfg.Assignments.Teams = map[string]string{"acme/platform-team": "editor"}
+
+	scopes := cfg.ScopesForUser("alice", nil)
+	if !Allows(scopes, ScopeAuditRead) {
+		t.Fatalf("expected alice's admin role to grant audit:read, got %v", scopes)
+	}
+
+	scopes = cfg.ScopesForUser("bob", []string{"acme/platform-team"})
+	if !Allows(scopes, ScopeManifestWriteTeam("platform")) {
+		t.Fatalf("expected bob's team membership to grant team/platform write, got %v", scopes)
+	}
+
+	scopes = cfg.ScopesForUser("alice", []string{"acme/platform-team"})
+	if !Allows(scopes, ScopeAuditRead) || !Allows(scopes, ScopeManifestWriteTeam("platform")) {
+		t.Fatalf("expected union of user and team scopes, got %v", scopes)
+	}
+
+	if scopes := cfg.ScopesForUser("nobody", nil); len(scopes) != 0 {
+		t.Fatalf("expected no scopes for unassigned user, got %v", scopes)
+	}
+}
@@ -0,0 +1,35 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/pkg/errors"
+)
+
+// FetchUserTeams returns the "org/team-slug" identifiers of every team
+// the authenticated client's user belongs to, for matching against
+// roles.yaml's assignments.teams.
+func FetchUserTeams(ctx context.Context, client *github.Client) ([]string, error) {
+	var teams []string
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Teams.ListUserTeams(ctx, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing user teams")
+		}
+		for _, t := range page {
+			if t.Organization == nil || t.Organization.Login == nil || t.Slug == nil {
+				continue
+			}
+			teams = append(teams, t.Organization.GetLogin()+"/"+t.GetSlug())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return teams, nil
+}
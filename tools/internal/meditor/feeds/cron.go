@@ -0,0 +1,146 @@
+package feeds
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxLookahead bounds how far NextRun will search before giving up,
+// guarding against a spec that can never match (e.g. "0 0 31 2 *",
+// February never having a 31st).
+const maxLookahead = 4 * 366 * 24 * time.Hour
+
+// cronField is one parsed field of a 5-field cron spec: the set of
+// values it matches, within [min, max].
+type cronField struct {
+	values map[int]struct{}
+}
+
+func (f cronField) matches(v int) bool {
+	_, ok := f.values[v]
+	return ok
+}
+
+// parseCronField parses one "," separated field (each piece a literal,
+// a "*", a "a-b" range, or any of those with a trailing "/step") into
+// the set of values it matches within [min, max].
+func parseCronField(field string, min, max int) (cronField, error) {
+	values := make(map[int]struct{})
+
+	for _, part := range strings.Split(field, ",") {
+		rangeSpec, step := part, 1
+		if idx := strings.IndexByte(part, '/'); idx != -1 {
+			rangeSpec = part[:idx]
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return cronField{}, errors.Errorf("invalid step in cron field %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangeSpec == "*":
+			// lo, hi already the full range.
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || a > b {
+				return cronField{}, errors.Errorf("invalid range in cron field %q", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(rangeSpec)
+			if err != nil {
+				return cronField{}, errors.Errorf("invalid value in cron field %q", part)
+			}
+			lo, hi = n, n
+		}
+
+		if lo < min || hi > max {
+			return cronField{}, errors.Errorf("cron field %q out of range [%d,%d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = struct{}{}
+		}
+	}
+
+	return cronField{values: values}, nil
+}
+
+// cronSpec is a parsed 5-field cron schedule.
+type cronSpec struct {
+	minute, hour, dom, month, dow cronField
+	// domRestricted/dowRestricted record whether the dom/dow field was
+	// "*", so NextRun can apply cron's OR-of-both-fields rule: if only
+	// one of the two is restricted, it alone constrains the match; if
+	// both are, either one matching is enough.
+	domRestricted, dowRestricted bool
+}
+
+// parseCron parses a standard 5-field "minute hour dom month dow" spec.
+func parseCron(spec string) (cronSpec, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return cronSpec{}, errors.Errorf("cron spec %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	minute, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return cronSpec{}, err
+	}
+	hour, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return cronSpec{}, err
+	}
+	dom, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return cronSpec{}, err
+	}
+	month, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return cronSpec{}, err
+	}
+	dow, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return cronSpec{}, err
+	}
+
+	return cronSpec{
+		minute: minute, hour: hour, dom: dom, month: month, dow: dow,
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+func (c cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom.matches(t.Day())
+	dowMatch := c.dow.matches(int(t.Weekday()))
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// NextRun returns the next time spec is due strictly after after,
+// truncated to the minute the way cron schedules fire.
+func NextRun(spec string, after time.Time) (time.Time, error) {
+	c, err := parseCron(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	deadline := after.Add(maxLookahead)
+	for t.Before(deadline) {
+		if c.month.matches(int(t.Month())) && c.dayMatches(t) && c.hour.matches(t.Hour()) && c.minute.matches(t.Minute()) {
+			return t, nil
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, errors.Errorf("cron spec %q: no match within %s", spec, maxLookahead)
+}
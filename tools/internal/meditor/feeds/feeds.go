@@ -0,0 +1,144 @@
+// Package feeds fetches external domain blocklists (URLhaus, OISD,
+// phishing lists, typo-squatting lists, or any other plain-text feed)
+// on a schedule declared in feeds.yaml, diffs each one against the
+// manifest it's mapped to, and reports what changed so a caller can
+// open a pull/merge request through a forge.Provider.
+package feeds
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Feed is one external domain list to sync, as declared in feeds.yaml.
+type Feed struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Cron is a standard 5-field cron spec (minute hour day-of-month
+	// month day-of-week) controlling how often this feed is checked.
+	Cron string `yaml:"cron"`
+	// Manifest is the manifest path this feed updates, relative to the
+	// manifests directory, e.g. "includes/phishing/urlhaus.json".
+	Manifest string `yaml:"manifest"`
+	// RuleType is "allow" or "block"; defaults to "block" if unset.
+	RuleType string `yaml:"rule_type,omitempty"`
+	// NeverRemove lists domains Diff never reports as removed, even
+	// when the upstream feed stops listing them - for entries added by
+	// hand for a reason the feed itself doesn't know about.
+	NeverRemove []string `yaml:"never_remove,omitempty"`
+}
+
+// Config is the parsed feeds.yaml.
+type Config struct {
+	Feeds []Feed `yaml:"feeds"`
+}
+
+// Load reads and parses feeds.yaml from path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading feeds.yaml")
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrap(err, "parsing feeds.yaml")
+	}
+	return &cfg, nil
+}
+
+// FetchDomains downloads url and parses it as a plain-text domain list -
+// the format URLhaus, OISD, and most phishing/typo-squatting feeds
+// publish, one domain per line (optionally as a hosts-file "0.0.0.0
+// domain" entry), ignoring blank lines and "#" comments.
+func FetchDomains(ctx context.Context, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "building feed request")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetching feed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetching feed: unexpected status %s", resp.Status)
+	}
+	return ParseDomainList(resp.Body)
+}
+
+// ParseDomainList extracts a deduplicated, sorted set of domains from a
+// plain-text feed body.
+func ParseDomainList(r io.Reader) ([]string, error) {
+	seen := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			line = fields[1]
+		} else {
+			line = fields[0]
+		}
+		seen[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "parsing feed body")
+	}
+
+	domains := make([]string, 0, len(seen))
+	for d := range seen {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// Diff compares a feed's freshly fetched domains against a manifest's
+// current list, returning the domains to add and the domains to remove.
+// Entries in neverRemove are kept even if the feed no longer lists them.
+func Diff(current, fetched, neverRemove []string) (added, removed []string) {
+	currentSet := make(map[string]struct{}, len(current))
+	for _, d := range current {
+		currentSet[d] = struct{}{}
+	}
+	fetchedSet := make(map[string]struct{}, len(fetched))
+	for _, d := range fetched {
+		fetchedSet[d] = struct{}{}
+	}
+	neverRemoveSet := make(map[string]struct{}, len(neverRemove))
+	for _, d := range neverRemove {
+		neverRemoveSet[d] = struct{}{}
+	}
+
+	for _, d := range fetched {
+		if _, ok := currentSet[d]; !ok {
+			added = append(added, d)
+		}
+	}
+	for _, d := range current {
+		if _, ok := fetchedSet[d]; ok {
+			continue
+		}
+		if _, ok := neverRemoveSet[d]; ok {
+			continue
+		}
+		removed = append(removed, d)
+	}
+	return added, removed
+}
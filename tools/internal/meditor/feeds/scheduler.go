@@ -0,0 +1,128 @@
+package feeds
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tickInterval is how often the scheduler checks whether any feed's
+// cron schedule is due - coarse enough to be cheap, fine enough that a
+// "* * * * *" (every minute) feed still fires within a tick of its spec.
+const tickInterval = 30 * time.Second
+
+// SyncFunc syncs one feed, returning the URL of the pull/merge request
+// it opened, or "" if the feed had nothing new to commit.
+type SyncFunc func(ctx context.Context, feed Feed) (prURL string, err error)
+
+// Status reports one feed's schedule state, for the /api/feeds endpoint.
+type Status struct {
+	Name    string    `json:"name"`
+	NextRun time.Time `json:"next_run"`
+	LastRun time.Time `json:"last_run,omitempty"`
+	LastErr string    `json:"last_error,omitempty"`
+	LastPR  string    `json:"last_pr_url,omitempty"`
+}
+
+// Scheduler runs sync for each configured feed when its cron schedule
+// comes due, tracking the result for Statuses to report.
+type Scheduler struct {
+	mu     sync.Mutex
+	status map[string]Status
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Start begins checking feeds against their cron schedules in a
+// background goroutine, calling sync whenever one comes due. The
+// returned Scheduler's goroutine runs until Close is called.
+func Start(feedList []Feed, sync SyncFunc) (*Scheduler, error) {
+	s := &Scheduler{
+		status: make(map[string]Status, len(feedList)),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	now := time.Now()
+	for _, f := range feedList {
+		next, err := NextRun(f.Cron, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "feed %q", f.Name)
+		}
+		s.status[f.Name] = Status{Name: f.Name, NextRun: next}
+	}
+
+	go s.loop(feedList, sync)
+	return s, nil
+}
+
+func (s *Scheduler) loop(feedList []Feed, sync SyncFunc) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			for _, f := range feedList {
+				s.runIfDue(f, now, sync)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) runIfDue(f Feed, now time.Time, sync SyncFunc) {
+	s.mu.Lock()
+	st := s.status[f.Name]
+	s.mu.Unlock()
+	if st.NextRun.After(now) {
+		return
+	}
+
+	prURL, err := sync(context.Background(), f)
+
+	st.LastRun = now
+	if err != nil {
+		st.LastErr = err.Error()
+	} else {
+		st.LastErr = ""
+		if prURL != "" {
+			st.LastPR = prURL
+		}
+	}
+	if next, nerr := NextRun(f.Cron, now); nerr == nil {
+		st.NextRun = next
+	}
+
+	s.mu.Lock()
+	s.status[f.Name] = st
+	s.mu.Unlock()
+}
+
+// Statuses returns every feed's current schedule status, sorted by name.
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Status, 0, len(s.status))
+	for _, st := range s.status {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Close stops the scheduler's background goroutine, waiting for any
+// sync currently in flight to return.
+func (s *Scheduler) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
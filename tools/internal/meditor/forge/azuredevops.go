@@ -0,0 +1,301 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// azureAPIVersion pins the Azure DevOps REST API version every request
+// below was written against.
+const azureAPIVersion = "7.1"
+
+// AzureDevOpsProvider implements Provider against the Azure DevOps
+// Services REST API, authenticating with a personal access token (PAT).
+// owner is "organization/project" (Azure DevOps has no bare owner the
+// way GitHub/GitLab/Gitea/Bitbucket do - a repo always lives under a
+// project within an organization), repo is the Git repository name.
+type AzureDevOpsProvider struct {
+	Token  string
+	Client *http.Client
+}
+
+// NewAzureDevOpsProvider returns a Provider for Azure DevOps Services,
+// authenticating API calls with a personal access token.
+func NewAzureDevOpsProvider(token string) *AzureDevOpsProvider {
+	return &AzureDevOpsProvider{Token: token}
+}
+
+func (p *AzureDevOpsProvider) httpClient() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
+
+// orgProject splits owner into its organization and project segments.
+func orgProject(owner string) (string, string) {
+	parts := strings.SplitN(owner, "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func (p *AzureDevOpsProvider) do(ctx context.Context, method, org, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	url := fmt.Sprintf("https://dev.azure.com/%s%s%sapi-version=%s", org, path, sep, azureAPIVersion)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+p.Token)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("azure devops API %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
+	org, project := orgProject(owner)
+	var refs struct {
+		Value []struct {
+			ObjectID string `json:"objectId"`
+		} `json:"value"`
+	}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s/refs?filter=heads/%s", project, repo, baseBranch)
+	if err := p.do(ctx, http.MethodGet, org, path, nil, &refs); err != nil {
+		return "", fmt.Errorf("failed to get base ref: %w", err)
+	}
+	if len(refs.Value) == 0 {
+		return "", fmt.Errorf("branch %s not found", baseBranch)
+	}
+	return refs.Value[0].ObjectID, nil
+}
+
+func (p *AzureDevOpsProvider) CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error {
+	org, project := orgProject(owner)
+	payload := []map[string]string{{
+		"name":        "refs/heads/" + branch,
+		"oldObjectId": "0000000000000000000000000000000000000000",
+		"newObjectId": baseSHA,
+	}}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s/refs", project, repo)
+	if err := p.do(ctx, http.MethodPost, org, path, payload, nil); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	return nil
+}
+
+// CommitFilesAtomic commits files to branch in one commit via a single
+// "push" against the repository's refs/heads/branch, Azure DevOps's
+// equivalent of GitHub's git-data tree/commit pair.
+func (p *AzureDevOpsProvider) CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	org, project := orgProject(owner)
+
+	type change struct {
+		ChangeType string `json:"changeType"`
+		Item       struct {
+			Path string `json:"path"`
+		} `json:"item"`
+		NewContent struct {
+			Content     string `json:"content"`
+			ContentType string `json:"contentType"`
+		} `json:"newContent"`
+	}
+	changes := make([]change, len(files))
+	for i, f := range files {
+		changes[i].ChangeType = "edit"
+		changes[i].Item.Path = f.Path
+		changes[i].NewContent.Content = f.ContentBase64
+		changes[i].NewContent.ContentType = "base64encoded"
+	}
+
+	payload := map[string]interface{}{
+		"refUpdates": []map[string]string{{"name": "refs/heads/" + branch, "oldObjectId": baseSHA}},
+		"commits": []map[string]interface{}{{
+			"comment": message,
+			"changes": changes,
+		}},
+	}
+
+	var push struct {
+		Commits []struct {
+			CommitID string `json:"commitId"`
+		} `json:"commits"`
+	}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s/pushes", project, repo)
+	if err := p.do(ctx, http.MethodPost, org, path, payload, &push); err != nil {
+		return "", fmt.Errorf("failed to commit files: %w", err)
+	}
+	if len(push.Commits) == 0 {
+		return "", fmt.Errorf("push returned no commits")
+	}
+	return push.Commits[0].CommitID, nil
+}
+
+func (p *AzureDevOpsProvider) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error) {
+	org, project := orgProject(owner)
+	payload := map[string]string{
+		"sourceRefName": "refs/heads/" + head,
+		"targetRefName": "refs/heads/" + base,
+		"title":         title,
+		"description":   body,
+	}
+
+	var pr struct {
+		PullRequestID int    `json:"pullRequestId"`
+		Title         string `json:"title"`
+	}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests", project, repo)
+	if err := p.do(ctx, http.MethodPost, org, path, payload, &pr); err != nil {
+		return PRInfo{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	url := fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s/pullrequest/%d", org, project, repo, pr.PullRequestID)
+	return PRInfo{Number: pr.PullRequestID, Title: pr.Title, URL: url, Head: head, Base: base}, nil
+}
+
+func (p *AzureDevOpsProvider) ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error) {
+	org, project := orgProject(owner)
+	var result struct {
+		Value []struct {
+			PullRequestID int    `json:"pullRequestId"`
+			Title         string `json:"title"`
+			SourceRefName string `json:"sourceRefName"`
+			TargetRefName string `json:"targetRefName"`
+		} `json:"value"`
+	}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests?searchCriteria.status=active", project, repo)
+	if err := p.do(ctx, http.MethodGet, org, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	out := make([]PRInfo, len(result.Value))
+	for i, pr := range result.Value {
+		url := fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s/pullrequest/%d", org, project, repo, pr.PullRequestID)
+		out[i] = PRInfo{
+			Number: pr.PullRequestID, Title: pr.Title, URL: url,
+			Head: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+			Base: strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+		}
+	}
+	return out, nil
+}
+
+// ListOpenPRsForPath lists active pull requests whose latest iteration
+// touches path. Azure DevOps has no server-side "touches this path"
+// filter for pull requests, so this lists every active one and checks
+// each one's latest iteration's changes in turn.
+func (p *AzureDevOpsProvider) ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error) {
+	org, project := orgProject(owner)
+	var result struct {
+		Value []struct {
+			PullRequestID int    `json:"pullRequestId"`
+			Status        string `json:"status"`
+			CreationDate  string `json:"creationDate"`
+			CreatedBy     struct {
+				DisplayName string `json:"displayName"`
+			} `json:"createdBy"`
+		} `json:"value"`
+	}
+	listPath := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests?searchCriteria.status=active", project, repo)
+	if err := p.do(ctx, http.MethodGet, org, listPath, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var matches []PRRef
+	for _, pr := range result.Value {
+		var iterations struct {
+			Value []struct {
+				ID int `json:"id"`
+			} `json:"value"`
+		}
+		iterPath := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests/%d/iterations", project, repo, pr.PullRequestID)
+		if err := p.do(ctx, http.MethodGet, org, iterPath, nil, &iterations); err != nil || len(iterations.Value) == 0 {
+			continue
+		}
+		latest := iterations.Value[len(iterations.Value)-1].ID
+
+		var changes struct {
+			ChangeEntries []struct {
+				Item struct {
+					Path string `json:"path"`
+				} `json:"item"`
+			} `json:"changeEntries"`
+		}
+		changesPath := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests/%d/iterations/%d/changes", project, repo, pr.PullRequestID, latest)
+		if err := p.do(ctx, http.MethodGet, org, changesPath, nil, &changes); err != nil {
+			return nil, fmt.Errorf("failed to list changes for PR #%d: %w", pr.PullRequestID, err)
+		}
+
+		for _, entry := range changes.ChangeEntries {
+			if entry.Item.Path != "/"+path && entry.Item.Path != path {
+				continue
+			}
+			createdAt, _ := time.Parse(time.RFC3339, pr.CreationDate)
+			matches = append(matches, PRRef{
+				Number: pr.PullRequestID, URL: fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s/pullrequest/%d", org, project, repo, pr.PullRequestID),
+				State: pr.Status, Author: pr.CreatedBy.DisplayName, UpdatedAt: createdAt,
+			})
+			break
+		}
+	}
+	return matches, nil
+}
+
+// ApplyReviewAndLabels applies labels to pull request number by name;
+// reviewers are skipped, since Azure DevOps's reviewer endpoint requires
+// a reviewer's numeric identity GUID rather than a login, and resolving
+// that would need a separate organization identity lookup this provider
+// doesn't otherwise need.
+func (p *AzureDevOpsProvider) ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	org, project := orgProject(owner)
+	for _, label := range labels {
+		path := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullrequests/%d/labels", project, repo, number)
+		if err := p.do(ctx, http.MethodPost, org, path, map[string]string{"name": label}, nil); err != nil {
+			return fmt.Errorf("failed to apply label %q: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// SignsCommits reports false: pushes made through Azure DevOps's Git API
+// aren't GPG-signed by the service.
+func (p *AzureDevOpsProvider) SignsCommits() bool { return false }
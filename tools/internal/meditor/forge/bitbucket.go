@@ -0,0 +1,289 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BitbucketProvider implements Provider against the Bitbucket Cloud REST
+// API (v2.0), authenticating with a workspace access token or app
+// password. owner is the workspace slug, repo the repository slug.
+type BitbucketProvider struct {
+	BaseURL string // defaults to "https://api.bitbucket.org" if empty
+	Token   string
+	Client  *http.Client
+}
+
+// NewBitbucketProvider returns a Provider for Bitbucket Cloud,
+// authenticating API calls with token as a Bearer credential.
+func NewBitbucketProvider(token string) *BitbucketProvider {
+	return &BitbucketProvider{Token: token}
+}
+
+func (p *BitbucketProvider) baseURL() string {
+	if p.BaseURL != "" {
+		return strings.TrimSuffix(p.BaseURL, "/")
+	}
+	return "https://api.bitbucket.org"
+}
+
+func (p *BitbucketProvider) httpClient() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
+
+func (p *BitbucketProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL()+"/2.0"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("bitbucket API %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+func (p *BitbucketProvider) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
+	var branch struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/refs/branches/%s", owner, repo, baseBranch)
+	if err := p.do(ctx, http.MethodGet, path, nil, &branch); err != nil {
+		return "", fmt.Errorf("failed to get base ref: %w", err)
+	}
+	return branch.Target.Hash, nil
+}
+
+func (p *BitbucketProvider) CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error {
+	payload := map[string]interface{}{
+		"name":   branch,
+		"target": map[string]string{"hash": baseSHA},
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/refs/branches", owner, repo)
+	if err := p.do(ctx, http.MethodPost, path, payload, nil); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	return nil
+}
+
+// CommitFilesAtomic commits files to branch in one commit via
+// Bitbucket's multipart "src" endpoint, the only write path its REST API
+// offers for creating a commit without a full clone - each file becomes
+// a form field named after its repo-relative path.
+func (p *BitbucketProvider) CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := w.CreateFormField(f.Path)
+		if err != nil {
+			return "", err
+		}
+		content, err := base64.StdEncoding.DecodeString(f.ContentBase64)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", f.Path, err)
+		}
+		if _, err := fw.Write(content); err != nil {
+			return "", err
+		}
+	}
+	_ = w.WriteField("branch", branch)
+	_ = w.WriteField("message", message)
+	_ = w.WriteField("parents", baseSHA)
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL()+fmt.Sprintf("/2.0/repositories/%s/%s/src", owner, repo), &buf)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to commit files: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return p.GetBaseRef(ctx, owner, repo, branch)
+}
+
+func (p *BitbucketProvider) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error) {
+	payload := map[string]interface{}{
+		"title":       title,
+		"description": body,
+		"source":      map[string]interface{}{"branch": map[string]string{"name": head}},
+		"destination": map[string]interface{}{"branch": map[string]string{"name": base}},
+	}
+
+	var pr struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests", owner, repo)
+	if err := p.do(ctx, http.MethodPost, path, payload, &pr); err != nil {
+		return PRInfo{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return PRInfo{Number: pr.ID, Title: pr.Title, URL: pr.Links.HTML.Href, Head: head, Base: base}, nil
+}
+
+func (p *BitbucketProvider) ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error) {
+	var page struct {
+		Values []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+			Source struct {
+				Branch struct {
+					Name string `json:"name"`
+				} `json:"branch"`
+			} `json:"source"`
+			Destination struct {
+				Branch struct {
+					Name string `json:"name"`
+				} `json:"branch"`
+			} `json:"destination"`
+		} `json:"values"`
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests?state=OPEN", owner, repo)
+	if err := p.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	out := make([]PRInfo, len(page.Values))
+	for i, pr := range page.Values {
+		out[i] = PRInfo{Number: pr.ID, Title: pr.Title, URL: pr.Links.HTML.Href, Head: pr.Source.Branch.Name, Base: pr.Destination.Branch.Name}
+	}
+	return out, nil
+}
+
+// ListOpenPRsForPath lists open pull requests whose diffstat includes
+// path. Bitbucket Cloud has no server-side "touches this path" filter
+// for pull requests, so this lists every open one and checks each one's
+// diffstat in turn.
+func (p *BitbucketProvider) ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error) {
+	var page struct {
+		Values []struct {
+			ID    int    `json:"id"`
+			State string `json:"state"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+			Author struct {
+				Nickname string `json:"nickname"`
+			} `json:"author"`
+			UpdatedOn string `json:"updated_on"`
+		} `json:"values"`
+	}
+	listPath := fmt.Sprintf("/repositories/%s/%s/pullrequests?state=OPEN", owner, repo)
+	if err := p.do(ctx, http.MethodGet, listPath, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var matches []PRRef
+	for _, pr := range page.Values {
+		var diffstat struct {
+			Values []struct {
+				New struct {
+					Path string `json:"path"`
+				} `json:"new"`
+				Old struct {
+					Path string `json:"path"`
+				} `json:"old"`
+			} `json:"values"`
+		}
+		diffstatPath := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/diffstat", owner, repo, pr.ID)
+		if err := p.do(ctx, http.MethodGet, diffstatPath, nil, &diffstat); err != nil {
+			return nil, fmt.Errorf("failed to list diffstat for PR #%d: %w", pr.ID, err)
+		}
+
+		for _, entry := range diffstat.Values {
+			if entry.New.Path != path && entry.Old.Path != path {
+				continue
+			}
+			updatedAt, _ := time.Parse(time.RFC3339, pr.UpdatedOn)
+			matches = append(matches, PRRef{
+				Number: pr.ID, URL: pr.Links.HTML.Href, State: pr.State,
+				Author: pr.Author.Nickname, UpdatedAt: updatedAt,
+			})
+			break
+		}
+	}
+	return matches, nil
+}
+
+// ApplyReviewAndLabels requests reviewers on pull request number by
+// updating it; Bitbucket Cloud has no labels concept, so labels is
+// always ignored.
+func (p *BitbucketProvider) ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	accounts := make([]map[string]string, len(reviewers))
+	for i, r := range reviewers {
+		accounts[i] = map[string]string{"username": r}
+	}
+	payload := map[string]interface{}{"reviewers": accounts}
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", owner, repo, number)
+	if err := p.do(ctx, http.MethodPut, path, payload, nil); err != nil {
+		return fmt.Errorf("failed to request reviewers: %w", err)
+	}
+	return nil
+}
+
+// SignsCommits reports false: Bitbucket Cloud's src endpoint doesn't
+// GPG-sign the commits it creates on the caller's behalf.
+func (p *BitbucketProvider) SignsCommits() bool { return false }
@@ -0,0 +1,101 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeProvider is an in-memory Provider for tests: it keeps branches,
+// commits, and pull requests in memory instead of calling out to a real
+// forge, so manifest-editor's PR flow can be exercised without network
+// access or credentials.
+type FakeProvider struct {
+	mu sync.Mutex
+
+	// Branches maps "owner/repo/branch" to the commit SHA it points at.
+	// Seed a base branch here before GetBaseRef is called against it.
+	Branches map[string]string
+	// PRs accumulates every pull/merge request OpenPR has created.
+	PRs []PRInfo
+	// ReviewAndLabels records the last ApplyReviewAndLabels call per PR
+	// number, for assertions.
+	ReviewAndLabels map[int]struct {
+		Reviewers []string
+		Labels    []string
+	}
+
+	nextCommit int
+	nextPR     int
+}
+
+// NewFakeProvider returns an empty FakeProvider.
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		Branches:        make(map[string]string),
+		ReviewAndLabels: make(map[int]struct{ Reviewers, Labels []string }),
+	}
+}
+
+func branchKey(owner, repo, branch string) string {
+	return owner + "/" + repo + "/" + branch
+}
+
+func (p *FakeProvider) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sha, ok := p.Branches[branchKey(owner, repo, baseBranch)]
+	if !ok {
+		return "", fmt.Errorf("branch %s not found", baseBranch)
+	}
+	return sha, nil
+}
+
+func (p *FakeProvider) CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Branches[branchKey(owner, repo, branch)] = baseSHA
+	return nil
+}
+
+func (p *FakeProvider) CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextCommit++
+	sha := fmt.Sprintf("fakecommit%d", p.nextCommit)
+	p.Branches[branchKey(owner, repo, branch)] = sha
+	return sha, nil
+}
+
+func (p *FakeProvider) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextPR++
+	pr := PRInfo{Number: p.nextPR, Title: title, URL: fmt.Sprintf("fake://%s/%s/pull/%d", owner, repo, p.nextPR), Head: head, Base: base}
+	p.PRs = append(p.PRs, pr)
+	return pr, nil
+}
+
+func (p *FakeProvider) ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]PRInfo, len(p.PRs))
+	copy(out, p.PRs)
+	return out, nil
+}
+
+// ListOpenPRsForPath always returns nil: FakeProvider doesn't track
+// which files a commit touched, since no caller has needed that yet.
+func (p *FakeProvider) ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error) {
+	return nil, nil
+}
+
+func (p *FakeProvider) ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ReviewAndLabels[number] = struct{ Reviewers, Labels []string }{reviewers, labels}
+	return nil
+}
+
+// SignsCommits reports false: FakeProvider's commits are never signed.
+func (p *FakeProvider) SignsCommits() bool { return false }
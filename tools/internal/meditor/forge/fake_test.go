@@ -0,0 +1,93 @@
+package forge
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFakeProviderPRFlow drives FakeProvider through the same
+// GetBaseRef/CreateBranch/CommitFilesAtomic/OpenPR/ApplyReviewAndLabels
+// sequence createGitHubPR runs against a real forge, so the flow itself
+// is exercised without network access or credentials.
+func TestFakeProviderPRFlow(t *testing.T) {
+	ctx := context.Background()
+	p := NewFakeProvider()
+	p.Branches["acme/manifests/main"] = "basesha1"
+
+	baseSHA, err := p.GetBaseRef(ctx, "acme", "manifests", "main")
+	if err != nil {
+		t.Fatalf("GetBaseRef: %v", err)
+	}
+	if baseSHA != "basesha1" {
+		t.Fatalf("GetBaseRef = %q, want basesha1", baseSHA)
+	}
+
+	if err := p.CreateBranch(ctx, "acme", "manifests", "feature-1", baseSHA); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+
+	files := []FileSpec{{Path: "includes/team/platform.json", ContentBase64: "ZmFrZQ=="}}
+	commitSHA, err := p.CommitFilesAtomic(ctx, "acme", "manifests", "feature-1", baseSHA, files, "update platform allowlist")
+	if err != nil {
+		t.Fatalf("CommitFilesAtomic: %v", err)
+	}
+	if commitSHA == "" {
+		t.Fatalf("CommitFilesAtomic returned empty SHA")
+	}
+	if got := p.Branches[branchKey("acme", "manifests", "feature-1")]; got != commitSHA {
+		t.Fatalf("branch feature-1 points at %q after commit, want %q", got, commitSHA)
+	}
+
+	pr, err := p.OpenPR(ctx, "acme", "manifests", "Update platform allowlist", "body", "feature-1", "main")
+	if err != nil {
+		t.Fatalf("OpenPR: %v", err)
+	}
+	if pr.Number == 0 || pr.Head != "feature-1" || pr.Base != "main" {
+		t.Fatalf("OpenPR = %+v, want non-zero Number, Head=feature-1, Base=main", pr)
+	}
+
+	if err := p.ApplyReviewAndLabels(ctx, "acme", "manifests", pr.Number, []string{"alice"}, []string{"manifest-change"}); err != nil {
+		t.Fatalf("ApplyReviewAndLabels: %v", err)
+	}
+
+	prs, err := p.ListOpenPRs(ctx, "acme", "manifests")
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != pr.Number {
+		t.Fatalf("ListOpenPRs = %+v, want single PR #%d", prs, pr.Number)
+	}
+
+	recorded, ok := p.ReviewAndLabels[pr.Number]
+	if !ok {
+		t.Fatalf("ApplyReviewAndLabels wasn't recorded for PR #%d", pr.Number)
+	}
+	if len(recorded.Reviewers) != 1 || recorded.Reviewers[0] != "alice" {
+		t.Fatalf("recorded reviewers = %v, want [alice]", recorded.Reviewers)
+	}
+	if len(recorded.Labels) != 1 || recorded.Labels[0] != "manifest-change" {
+		t.Fatalf("recorded labels = %v, want [manifest-change]", recorded.Labels)
+	}
+
+	if p.SignsCommits() {
+		t.Fatalf("FakeProvider.SignsCommits() = true, want false")
+	}
+}
+
+func TestFakeProviderGetBaseRefUnknownBranch(t *testing.T) {
+	p := NewFakeProvider()
+	if _, err := p.GetBaseRef(context.Background(), "acme", "manifests", "missing"); err == nil {
+		t.Fatalf("GetBaseRef on unseeded branch returned nil error, want an error")
+	}
+}
+
+func TestFakeProviderListOpenPRsForPath(t *testing.T) {
+	p := NewFakeProvider()
+	refs, err := p.ListOpenPRsForPath(context.Background(), "acme", "manifests", "includes/team/platform.json")
+	if err != nil {
+		t.Fatalf("ListOpenPRsForPath: %v", err)
+	}
+	if refs != nil {
+		t.Fatalf("ListOpenPRsForPath = %v, want nil", refs)
+	}
+}
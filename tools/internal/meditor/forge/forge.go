@@ -0,0 +1,73 @@
+// Package forge abstracts the branch/commit/pull-request workflow
+// manifest-editor's PR handlers need, so they can target GitHub, GitLab,
+// Gitea, Bitbucket, or Azure DevOps without caring which one a given
+// deployment points at. "PR" in method and type names stands for each
+// forge's own review-request concept - a pull request on GitHub, Gitea,
+// Bitbucket, and Azure DevOps, a merge request on GitLab.
+package forge
+
+import (
+	"context"
+	"time"
+)
+
+// FileSpec is one file's full contents to commit, base64-encoded the
+// same way the GitHub Contents/Git Data APIs expect.
+type FileSpec struct {
+	Path          string
+	ContentBase64 string
+}
+
+// PRInfo describes one pull/merge request in forge-neutral terms.
+type PRInfo struct {
+	Number int
+	Title  string
+	URL    string
+	Head   string
+	Base   string
+}
+
+// PRRef is a compact reference to an open pull/merge request that
+// touches a specific file, for display alongside a manifest a user is
+// about to edit.
+type PRRef struct {
+	Number    int       `json:"number"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"`
+	Author    string    `json:"author"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Provider performs the operations manifest-editor needs against a
+// specific forge's API for a single owner/repo pair passed to every call.
+type Provider interface {
+	// GetBaseRef returns the commit SHA baseBranch currently points at,
+	// the fork point a new feature branch is created from.
+	GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error)
+	// CreateBranch creates branch pointing at baseSHA.
+	CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error
+	// CommitFilesAtomic commits files to branch in as few commits as the
+	// forge's API allows and returns the resulting HEAD commit's SHA.
+	CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error)
+	// OpenPR opens a pull/merge request proposing to merge head into base.
+	OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error)
+	// ListOpenPRs lists currently open pull/merge requests targeting repo.
+	ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error)
+	// ListOpenPRsForPath lists currently open pull/merge requests that
+	// touch path (repo-relative, e.g. "manifests/includes/phishing/urlhaus.json").
+	ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error)
+	// ApplyReviewAndLabels requests reviewers and applies labels to an
+	// already-open pull/merge request. Either slice may be empty. A forge
+	// that can't resolve an entry (e.g. a reviewer login it has no API to
+	// turn into the numeric ID its review-request endpoint requires)
+	// skips that entry rather than failing the call, since a PR that
+	// opened successfully shouldn't be reported as an error over optional
+	// metadata that didn't fully apply.
+	ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error
+	// SignsCommits reports whether CommitFilesAtomic's commits come back
+	// cryptographically signed ("Verified") by the forge itself, the way
+	// GitHub's Git Data API signs commits made with an App installation
+	// token. Callers use this to decide whether a signed-commits policy
+	// can be satisfied on the configured forge.
+	SignsCommits() bool
+}
@@ -0,0 +1,387 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gogithttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v66/github"
+)
+
+// ClientFactory mints a *github.Client for a request, the way
+// manifest-editor's own GitHubClientFactory does: honoring a forced App
+// installation token (for Verified commits) or an on-behalf-of user
+// token carried on ctx, falling back to the installation token. Provider
+// calls it fresh per method, matching how the token those context values
+// select can differ between, say, CommitFilesAtomic and OpenPR.
+type ClientFactory interface {
+	NewClient(ctx context.Context) (*github.Client, error)
+	// Token resolves the same access token NewClient's client would
+	// authenticate API calls with, for callers (clone-mode commits) that
+	// need the raw token rather than a *github.Client.
+	Token(ctx context.Context) (string, error)
+}
+
+// defaultCloneModeFileThreshold is CommitFilesAtomic's default cutover
+// point from the blob/tree/commit API (one CreateBlob round trip per
+// file) to cloning the repo locally and pushing a single commit, once
+// that many files or more are being committed in one call.
+const defaultCloneModeFileThreshold = 10
+
+// GitHubProvider implements Provider against the GitHub REST API.
+type GitHubProvider struct {
+	Factory ClientFactory
+
+	// CloneModeFileThreshold overrides defaultCloneModeFileThreshold when
+	// positive.
+	CloneModeFileThreshold int
+	// CloneBaseURL overrides the "https://github.com" clone-mode clones
+	// from, for GitHub Enterprise Server deployments.
+	CloneBaseURL string
+	// SigningKey, if set, GPG-signs clone-mode commits with it. Commits
+	// made via the API-mode path are never signed this way - GitHub
+	// already marks installation-token commits "Verified" on its own, so
+	// signing only matters for the clone-mode path this key selects.
+	SigningKey *openpgp.Entity
+}
+
+// usesCloneMode reports whether CommitFilesAtomic should clone the repo
+// locally and push one commit instead of calling the blob/tree/commit
+// API: once enough files make the per-file API round trips costlier than
+// a clone, or whenever a signing key is configured, since the API mode
+// has no way to GPG-sign the commits it creates.
+func (p *GitHubProvider) usesCloneMode(fileCount int) bool {
+	if p.SigningKey != nil {
+		return true
+	}
+	threshold := p.CloneModeFileThreshold
+	if threshold <= 0 {
+		threshold = defaultCloneModeFileThreshold
+	}
+	return fileCount >= threshold
+}
+
+func (p *GitHubProvider) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ref, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to get base ref: %w", err)
+	}
+	return ref.Object.GetSHA(), nil
+}
+
+func (p *GitHubProvider) CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(baseSHA)},
+	}
+	if _, _, err := client.Git.CreateRef(ctx, owner, repo, ref); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	return nil
+}
+
+// CommitFilesAtomic dispatches to the blob/tree/commit API or to a local
+// clone based on usesCloneMode.
+func (p *GitHubProvider) CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	if p.usesCloneMode(len(files)) {
+		return p.commitFilesAtomicClone(ctx, owner, repo, branch, baseSHA, files, message)
+	}
+	return p.commitFilesAtomicAPI(ctx, owner, repo, branch, baseSHA, files, message)
+}
+
+// commitFilesAtomicMaxRebase bounds how many times commitFilesAtomicAPI
+// will re-fetch branch's current tip and rebuild its tree/commit against
+// it after a non-fast-forward UpdateRef, for busy repos where another PR
+// lands on the same feature branch between GetBaseRef and this call.
+const commitFilesAtomicMaxRebase = 3
+
+func (p *GitHubProvider) commitFilesAtomicAPI(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	treeEntries := make([]*github.TreeEntry, 0, len(files))
+	for _, file := range files {
+		blob, _, err := client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+			Content:  github.String(file.ContentBase64),
+			Encoding: github.String("base64"),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create blob for %s: %w", file.Path, err)
+		}
+		treeEntries = append(treeEntries, &github.TreeEntry{
+			Path: github.String(file.Path),
+			Mode: github.String("100644"),
+			Type: github.String("blob"),
+			SHA:  github.String(blob.GetSHA()),
+		})
+	}
+
+	for attempt := 0; ; attempt++ {
+		baseCommit, _, err := client.Git.GetCommit(ctx, owner, repo, baseSHA)
+		if err != nil {
+			return "", fmt.Errorf("failed to get base commit: %w", err)
+		}
+
+		tree, _, err := client.Git.CreateTree(ctx, owner, repo, baseCommit.Tree.GetSHA(), treeEntries)
+		if err != nil {
+			return "", fmt.Errorf("failed to create tree: %w", err)
+		}
+
+		commit, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+			Message: github.String(message),
+			Tree:    tree,
+			Parents: []*github.Commit{baseCommit},
+		}, &github.CreateCommitOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to create commit: %w", err)
+		}
+
+		ref := &github.Reference{
+			Ref:    github.String("refs/heads/" + branch),
+			Object: &github.GitObject{SHA: commit.SHA},
+		}
+		_, resp, err := client.Git.UpdateRef(ctx, owner, repo, ref, false)
+		if err == nil {
+			return commit.GetSHA(), nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnprocessableEntity || attempt >= commitFilesAtomicMaxRebase {
+			return "", fmt.Errorf("failed to update ref: %w", err)
+		}
+
+		// Non-fast-forward: branch moved since baseSHA was read (e.g. a
+		// concurrent commit onto the same feature branch). Re-read its
+		// current tip and rebuild the tree/commit off that instead of
+		// clobbering whatever landed there.
+		current, _, refErr := client.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+		if refErr != nil {
+			return "", fmt.Errorf("failed to update ref: %w", err)
+		}
+		baseSHA = current.Object.GetSHA()
+	}
+}
+
+// commitFilesAtomicClone shallow-clones owner/repo into a tempdir under
+// os.TempDir(), writes files to disk, commits them in one commit
+// (PGP-signed with SigningKey if set), pushes branch, and removes the
+// clone - trading the API mode's N+4 round trips for one clone and one
+// push, and unlocking a commit signature the API mode can't produce.
+func (p *GitHubProvider) commitFilesAtomicClone(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	token, err := p.Factory.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "dnshield-commit-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create clone tempdir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cloneURL := fmt.Sprintf("%s/%s/%s.git", p.cloneBaseURL(), owner, repo)
+	auth := &gogithttp.BasicAuth{Username: "x-access-token", Password: token}
+	repository, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+		URL:           cloneURL,
+		Auth:          auth,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		Depth:         1,
+		SingleBranch:  true,
+	})
+	if err != nil {
+		// branch doesn't exist as a ref yet (CreateBranch already made it
+		// point at baseSHA, but a shallow single-branch clone can still
+		// fail to resolve it on some server configurations) - fall back
+		// to cloning the default branch and checking the commit's tree
+		// out from there instead.
+		repository, err = git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{URL: cloneURL, Auth: auth, Depth: 1})
+		if err != nil {
+			return "", fmt.Errorf("failed to clone repository: %w", err)
+		}
+	}
+
+	wt, err := repository.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open worktree: %w", err)
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+		if err := repository.Storer.SetReference(plumbing.NewHashReference(branchRef, plumbing.NewHash(baseSHA))); err != nil {
+			return "", fmt.Errorf("failed to create local branch: %w", err)
+		}
+		if err := wt.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+			return "", fmt.Errorf("failed to check out branch: %w", err)
+		}
+	}
+
+	for _, f := range files {
+		content, err := base64.StdEncoding.DecodeString(f.ContentBase64)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", f.Path, err)
+		}
+		fullPath := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(fullPath, content, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		if _, err := wt.Add(f.Path); err != nil {
+			return "", fmt.Errorf("failed to stage %s: %w", f.Path, err)
+		}
+	}
+
+	commitOpts := &git.CommitOptions{
+		Author: &object.Signature{Name: "dnshield manifest-editor", Email: "manifest-editor@dnshield.invalid", When: time.Now()},
+	}
+	if p.SigningKey != nil {
+		commitOpts.SignKey = p.SigningKey
+	}
+	commitHash, err := wt.Commit(message, commitOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit files: %w", err)
+	}
+
+	if err := repository.PushContext(ctx, &git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(branchRef + ":" + branchRef)},
+		Auth:       auth,
+		Force:      true,
+	}); err != nil {
+		return "", fmt.Errorf("failed to push branch: %w", err)
+	}
+
+	return commitHash.String(), nil
+}
+
+// cloneBaseURL is the HTTPS host clone-mode clones from, overridable for
+// GitHub Enterprise Server deployments whose repos don't live under
+// github.com.
+func (p *GitHubProvider) cloneBaseURL() string {
+	if p.CloneBaseURL != "" {
+		return strings.TrimSuffix(p.CloneBaseURL, "/")
+	}
+	return "https://github.com"
+}
+
+func (p *GitHubProvider) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error) {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return PRInfo{}, err
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(head),
+		Base:  github.String(base),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return PRInfo{}, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return PRInfo{Number: pr.GetNumber(), Title: pr.GetTitle(), URL: pr.GetHTMLURL(), Head: head, Base: base}, nil
+}
+
+func (p *GitHubProvider) ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error) {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{State: "open"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	out := make([]PRInfo, len(prs))
+	for i, pr := range prs {
+		out[i] = PRInfo{Number: pr.GetNumber(), Title: pr.GetTitle(), URL: pr.GetHTMLURL(), Head: pr.GetHead().GetRef(), Base: pr.GetBase().GetRef()}
+	}
+	return out, nil
+}
+
+// ListOpenPRsForPath lists open pull requests whose changed files
+// include path. The GitHub API has no server-side "touches this path"
+// filter for pull requests, so this lists every open PR and checks each
+// one's changed files in turn.
+func (p *GitHubProvider) ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error) {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{State: "open"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var matches []PRRef
+	for _, pr := range prs {
+		files, _, err := client.PullRequests.ListFiles(ctx, owner, repo, pr.GetNumber(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files for PR #%d: %w", pr.GetNumber(), err)
+		}
+		for _, f := range files {
+			if f.GetFilename() != path {
+				continue
+			}
+			matches = append(matches, PRRef{
+				Number:    pr.GetNumber(),
+				URL:       pr.GetHTMLURL(),
+				State:     pr.GetState(),
+				Author:    pr.GetUser().GetLogin(),
+				UpdatedAt: pr.GetUpdatedAt().Time,
+			})
+			break
+		}
+	}
+	return matches, nil
+}
+
+// ApplyReviewAndLabels requests reviewers and applies labels to PR
+// number via the Pull Requests and Issues APIs respectively. Either call
+// is skipped if its slice is empty.
+func (p *GitHubProvider) ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error {
+	client, err := p.Factory.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(reviewers) > 0 {
+		if _, _, err := client.PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{Reviewers: reviewers}); err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+	if len(labels) > 0 {
+		if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, number, labels); err != nil {
+			return fmt.Errorf("failed to apply labels: %w", err)
+		}
+	}
+	return nil
+}
+
+// SignsCommits reports true: commits made with a GitHub App installation
+// token come back marked "Verified".
+func (p *GitHubProvider) SignsCommits() bool { return true }
@@ -0,0 +1,259 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitLabProvider implements Provider against the GitLab REST API (v4),
+// authenticating with a personal/project access token.
+type GitLabProvider struct {
+	BaseURL string // e.g. "https://gitlab.com" or a self-managed instance
+	Token   string
+	Client  *http.Client
+}
+
+// NewGitLabProvider returns a Provider for the GitLab instance at
+// baseURL, authenticating API calls with token.
+func NewGitLabProvider(baseURL, token string) *GitLabProvider {
+	return &GitLabProvider{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+func (p *GitLabProvider) httpClient() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
+
+// projectPath is the "%2F"-escaped "owner/repo" form GitLab's API uses in
+// place of a numeric project ID.
+func projectPath(owner, repo string) string {
+	return url.PathEscape(owner + "/" + repo)
+}
+
+func (p *GitLabProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.BaseURL+"/api/v4"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", p.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("gitlab API %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+func (p *GitLabProvider) GetBaseRef(ctx context.Context, owner, repo, baseBranch string) (string, error) {
+	var branch struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	path := fmt.Sprintf("/projects/%s/repository/branches/%s", projectPath(owner, repo), url.PathEscape(baseBranch))
+	if err := p.do(ctx, http.MethodGet, path, nil, &branch); err != nil {
+		return "", fmt.Errorf("failed to get base ref: %w", err)
+	}
+	return branch.Commit.ID, nil
+}
+
+func (p *GitLabProvider) CreateBranch(ctx context.Context, owner, repo, branch, baseSHA string) error {
+	path := fmt.Sprintf("/projects/%s/repository/branches?branch=%s&ref=%s",
+		projectPath(owner, repo), url.QueryEscape(branch), url.QueryEscape(baseSHA))
+	if err := p.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	return nil
+}
+
+// CommitFilesAtomic commits files to branch in a single commit via
+// GitLab's multi-file commit API. Files are committed as "update"
+// actions, since manifest-editor only ever uses this to commit changes
+// to manifests that already exist in the target repo.
+func (p *GitLabProvider) CommitFilesAtomic(ctx context.Context, owner, repo, branch, baseSHA string, files []FileSpec, message string) (string, error) {
+	type action struct {
+		Action   string `json:"action"`
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	actions := make([]action, len(files))
+	for i, f := range files {
+		actions[i] = action{Action: "update", FilePath: f.Path, Content: f.ContentBase64, Encoding: "base64"}
+	}
+
+	payload := map[string]interface{}{
+		"branch":         branch,
+		"commit_message": message,
+		"actions":        actions,
+	}
+
+	var commit struct {
+		ID string `json:"id"`
+	}
+	path := fmt.Sprintf("/projects/%s/repository/commits", projectPath(owner, repo))
+	if err := p.do(ctx, http.MethodPost, path, payload, &commit); err != nil {
+		return "", fmt.Errorf("failed to commit files: %w", err)
+	}
+	return commit.ID, nil
+}
+
+func (p *GitLabProvider) OpenPR(ctx context.Context, owner, repo, title, body, head, base string) (PRInfo, error) {
+	payload := map[string]interface{}{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}
+
+	var mr struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+	}
+	path := fmt.Sprintf("/projects/%s/merge_requests", projectPath(owner, repo))
+	if err := p.do(ctx, http.MethodPost, path, payload, &mr); err != nil {
+		return PRInfo{}, fmt.Errorf("failed to create merge request: %w", err)
+	}
+	return PRInfo{Number: mr.IID, Title: mr.Title, URL: mr.WebURL, Head: head, Base: base}, nil
+}
+
+func (p *GitLabProvider) ListOpenPRs(ctx context.Context, owner, repo string) ([]PRInfo, error) {
+	var mrs []struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		WebURL       string `json:"web_url"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+	}
+	path := fmt.Sprintf("/projects/%s/merge_requests?state=opened", projectPath(owner, repo))
+	if err := p.do(ctx, http.MethodGet, path, nil, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+
+	out := make([]PRInfo, len(mrs))
+	for i, mr := range mrs {
+		out[i] = PRInfo{Number: mr.IID, Title: mr.Title, URL: mr.WebURL, Head: mr.SourceBranch, Base: mr.TargetBranch}
+	}
+	return out, nil
+}
+
+// ListOpenPRsForPath lists open merge requests whose diff touches path.
+// GitLab has no server-side "touches this path" filter for merge
+// requests, so this lists every open one and checks each one's changes
+// in turn.
+func (p *GitLabProvider) ListOpenPRsForPath(ctx context.Context, owner, repo, path string) ([]PRRef, error) {
+	var mrs []struct {
+		IID       int    `json:"iid"`
+		WebURL    string `json:"web_url"`
+		State     string `json:"state"`
+		UpdatedAt string `json:"updated_at"`
+		Author    struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	listPath := fmt.Sprintf("/projects/%s/merge_requests?state=opened", projectPath(owner, repo))
+	if err := p.do(ctx, http.MethodGet, listPath, nil, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+
+	var matches []PRRef
+	for _, mr := range mrs {
+		var diff struct {
+			Changes []struct {
+				OldPath string `json:"old_path"`
+				NewPath string `json:"new_path"`
+			} `json:"changes"`
+		}
+		changesPath := fmt.Sprintf("/projects/%s/merge_requests/%d/changes", projectPath(owner, repo), mr.IID)
+		if err := p.do(ctx, http.MethodGet, changesPath, nil, &diff); err != nil {
+			return nil, fmt.Errorf("failed to list changes for merge request !%d: %w", mr.IID, err)
+		}
+
+		for _, c := range diff.Changes {
+			if c.NewPath != path && c.OldPath != path {
+				continue
+			}
+			updatedAt, _ := time.Parse(time.RFC3339, mr.UpdatedAt)
+			matches = append(matches, PRRef{
+				Number: mr.IID, URL: mr.WebURL, State: mr.State,
+				Author: mr.Author.Username, UpdatedAt: updatedAt,
+			})
+			break
+		}
+	}
+	return matches, nil
+}
+
+// ApplyReviewAndLabels requests reviewers and applies labels to merge
+// request number by updating it. GitLab's update endpoint wants
+// reviewer_ids (numeric), not usernames, so each reviewer login is
+// resolved to a user ID first; a login that can't be resolved is
+// skipped rather than failing the whole call.
+func (p *GitLabProvider) ApplyReviewAndLabels(ctx context.Context, owner, repo string, number int, reviewers, labels []string) error {
+	var reviewerIDs []int
+	for _, login := range reviewers {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		path := fmt.Sprintf("/users?username=%s", url.QueryEscape(login))
+		if err := p.do(ctx, http.MethodGet, path, nil, &users); err != nil || len(users) == 0 {
+			continue
+		}
+		reviewerIDs = append(reviewerIDs, users[0].ID)
+	}
+
+	if len(reviewerIDs) == 0 && len(labels) == 0 {
+		return nil
+	}
+
+	payload := map[string]interface{}{}
+	if len(reviewerIDs) > 0 {
+		payload["reviewer_ids"] = reviewerIDs
+	}
+	if len(labels) > 0 {
+		payload["labels"] = strings.Join(labels, ",")
+	}
+
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d", projectPath(owner, repo), number)
+	if err := p.do(ctx, http.MethodPut, path, payload, nil); err != nil {
+		return fmt.Errorf("failed to update merge request !%d: %w", number, err)
+	}
+	return nil
+}
+
+// SignsCommits reports false: commits made through GitLab's commits API
+// aren't GPG-signed by GitLab the way a GitHub App installation token's
+// commits are.
+func (p *GitLabProvider) SignsCommits() bool { return false }
@@ -0,0 +1,213 @@
+package forge
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRateLimitReserve is how much of the primary rate limit
+// RateLimitTransport keeps in reserve: once X-RateLimit-Remaining drops
+// to this or below, it blocks new requests until the window resets
+// rather than letting the org's GitHub App installation get fully
+// throttled by GitHub itself.
+const defaultRateLimitReserve = 50
+
+// defaultRateLimitMaxRetries bounds RateLimitTransport's backoff loop for
+// transient 5xx responses, so a persistently failing upstream fails the
+// caller instead of retrying forever.
+const defaultRateLimitMaxRetries = 4
+
+// RateLimitMetrics counts RateLimitTransport's request volume, for
+// exposing alongside manifest-editor's other operational counters.
+type RateLimitMetrics struct {
+	Requests  uint64
+	Retries   uint64
+	Remaining int64
+}
+
+// Snapshot returns the metrics' current values. Safe to call
+// concurrently with RateLimitTransport.RoundTrip.
+func (m *RateLimitMetrics) Snapshot() (requests, retries uint64, remaining int64) {
+	return atomic.LoadUint64(&m.Requests), atomic.LoadUint64(&m.Retries), atomic.LoadInt64(&m.Remaining)
+}
+
+// RateLimitTransport wraps an http.RoundTripper with GitHub's rate-limit
+// and secondary rate-limit conventions: it blocks new requests once the
+// primary limit's remaining budget drops to Reserve or below until the
+// window resets, honors Retry-After on 403/429 secondary-limit
+// responses, and retries idempotent GET requests with exponential
+// backoff and jitter on transient 5xx responses. CommitFilesAtomic's
+// clone-mode path and every *github.Client manifest-editor builds share
+// one of these so a busy org editing many manifests concurrently doesn't
+// crash the commit/PR flow on the first 403 or 502.
+type RateLimitTransport struct {
+	// Base is the underlying RoundTripper; http.DefaultTransport if nil.
+	Base http.RoundTripper
+	// Reserve is the X-RateLimit-Remaining floor below which requests
+	// block until reset; defaultRateLimitReserve if zero.
+	Reserve int
+	// MaxRetries bounds retries of idempotent requests on 5xx;
+	// defaultRateLimitMaxRetries if zero.
+	MaxRetries int
+	// Metrics, if set, is updated with every request this transport
+	// makes. Optional - nil means don't track.
+	Metrics *RateLimitMetrics
+}
+
+func (t *RateLimitTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RateLimitTransport) reserve() int {
+	if t.Reserve > 0 {
+		return t.Reserve
+	}
+	return defaultRateLimitReserve
+}
+
+func (t *RateLimitTransport) maxRetries() int {
+	if t.MaxRetries > 0 {
+		return t.MaxRetries
+	}
+	return defaultRateLimitMaxRetries
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Metrics != nil {
+		atomic.AddUint64(&t.Metrics.Requests, 1)
+	}
+
+	if wait := t.blockUntilBudget(); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = t.base().RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+
+		t.recordRateLimit(resp)
+
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			if wait, ok := retryAfter(resp); ok && attempt < t.maxRetries() {
+				drainAndClose(resp)
+				if t.Metrics != nil {
+					atomic.AddUint64(&t.Metrics.Retries, 1)
+				}
+				time.Sleep(wait)
+				continue
+			}
+			return resp, nil
+		}
+
+		if idempotent && resp.StatusCode >= 500 && attempt < t.maxRetries() {
+			drainAndClose(resp)
+			if t.Metrics != nil {
+				atomic.AddUint64(&t.Metrics.Retries, 1)
+			}
+			time.Sleep(backoff(attempt))
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+// blockUntilBudget returns how long to sleep before sending the next
+// request, based on the remaining budget RateLimitTransport last
+// observed: zero unless that budget is at or below Reserve and the
+// reset time the prior response reported is still in the future.
+func (t *RateLimitTransport) blockUntilBudget() time.Duration {
+	if t.Metrics == nil {
+		return 0
+	}
+	remaining := atomic.LoadInt64(&t.Metrics.Remaining)
+	if remaining > int64(t.reserve()) {
+		return 0
+	}
+	reset := atomic.LoadInt64(&rateLimitResetUnix)
+	if reset == 0 {
+		return 0
+	}
+	wait := time.Until(time.Unix(reset, 0))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// rateLimitResetUnix is the most recently observed X-RateLimit-Reset
+// value, shared across all RateLimitTransport instances talking to the
+// same installation since GitHub's primary rate limit is per-token, not
+// per-transport.
+var rateLimitResetUnix int64
+
+func (t *RateLimitTransport) recordRateLimit(resp *http.Response) {
+	if remaining, ok := parseIntHeader(resp.Header, "X-RateLimit-Remaining"); ok && t.Metrics != nil {
+		atomic.StoreInt64(&t.Metrics.Remaining, remaining)
+	}
+	if reset, ok := parseIntHeader(resp.Header, "X-RateLimit-Reset"); ok {
+		atomic.StoreInt64(&rateLimitResetUnix, reset)
+	}
+}
+
+func parseIntHeader(h http.Header, key string) (int64, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// retryAfter reports how long to wait before retrying resp's request,
+// per its Retry-After header (seconds, or an HTTP date).
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// backoff returns attempt's exponential backoff delay with jitter: 500ms
+// * 2^attempt, plus up to 250ms of jitter to avoid every in-flight
+// request retrying in lockstep.
+func backoff(attempt int) time.Duration {
+	base := 500 * time.Millisecond
+	delay := base << attempt
+	jitter := time.Duration(rand.Int63n(int64(250 * time.Millisecond)))
+	return delay + jitter
+}
+
+func drainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,173 @@
+// Package policy parses .dnshield.yml, a repository-level file checked
+// into the target repo alongside roles.yaml that governs how
+// manifest-editor opens pull requests for a given manifest path: who
+// reviews it, what labels and base branch it gets, and how its title
+// and body are templated when the caller doesn't supply their own.
+package policy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule governs pull requests touching manifest paths matching Path, a
+// filepath.Match glob evaluated against the path relative to the
+// manifests directory (e.g. "includes/phishing/*").
+type Rule struct {
+	Path          string   `yaml:"path"`
+	Reviewers     []string `yaml:"reviewers,omitempty"`
+	Labels        []string `yaml:"labels,omitempty"`
+	BaseBranch    string   `yaml:"base_branch,omitempty"`
+	TitleTemplate string   `yaml:"title_template,omitempty"`
+	BodyTemplate  string   `yaml:"body_template,omitempty"`
+}
+
+// Config is the parsed .dnshield.yml: an ordered list of rules, the
+// first matching one applying to a given path.
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// TemplateData is what a rule's title/body templates render against,
+// covering the fields a reviewer needs to judge a domain-list change
+// without opening the diff: what manifest changed, which domains moved,
+// and who asked for it.
+type TemplateData struct {
+	ManifestName   string
+	Category       string
+	RuleType       string
+	AddedDomains   []string
+	RemovedDomains []string
+	TotalDomains   int
+	UserHandle     string
+	Timestamp      time.Time
+	// Diff is a short unified-diff-style rendering of the domains added
+	// ("+domain") and removed ("-domain"), for templates that want to
+	// embed it verbatim in a fenced code block.
+	Diff string
+}
+
+// DefaultTitleTemplate is used when neither the caller nor a matching
+// rule supplies a PR title, in the spirit of Dependabot's "Bump X from A
+// to B" default commit titles.
+const DefaultTitleTemplate = `Update {{.ManifestName}}: +{{len .AddedDomains}}/-{{len .RemovedDomains}} domains`
+
+// DefaultBodyTemplate is used when neither the caller nor a matching
+// rule supplies a PR body.
+const DefaultBodyTemplate = `Opened by manifest-editor on behalf of {{.UserHandle}} at {{.Timestamp.Format "2006-01-02 15:04:05 MST"}}.
+
+**Manifest:** {{.ManifestName}} ({{.Category}}/{{.RuleType}})
+**Total domains after merge:** {{.TotalDomains}}
+{{if .AddedDomains}}
+**Added ({{len .AddedDomains}}):**
+{{range .AddedDomains}}- {{.}}
+{{end}}{{end}}{{if .RemovedDomains}}
+**Removed ({{len .RemovedDomains}}):**
+{{range .RemovedDomains}}- {{.}}
+{{end}}{{end}}`
+
+// Load reads and parses .dnshield.yml from path, validating every rule's
+// path glob and templates (and the built-in defaults) up front so a typo
+// surfaces at load time rather than the next time a PR happens to hit
+// that rule.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading .dnshield.yml")
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrap(err, "parsing .dnshield.yml")
+	}
+	if err := validate(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func validate(cfg *Config) error {
+	if _, err := template.New("policy").Parse(DefaultTitleTemplate); err != nil {
+		return errors.Wrap(err, "default title template")
+	}
+	if _, err := template.New("policy").Parse(DefaultBodyTemplate); err != nil {
+		return errors.Wrap(err, "default body template")
+	}
+
+	for i, rule := range cfg.Rules {
+		if rule.Path == "" {
+			return errors.Errorf(".dnshield.yml: rule %d is missing a path", i)
+		}
+		if _, err := filepath.Match(rule.Path, "probe"); err != nil {
+			return errors.Wrapf(err, ".dnshield.yml: rule %d has an invalid path glob %q", i, rule.Path)
+		}
+		if rule.TitleTemplate != "" {
+			if _, err := template.New("policy").Parse(rule.TitleTemplate); err != nil {
+				return errors.Wrapf(err, ".dnshield.yml: rule %d (%s) has an invalid title_template", i, rule.Path)
+			}
+		}
+		if rule.BodyTemplate != "" {
+			if _, err := template.New("policy").Parse(rule.BodyTemplate); err != nil {
+				return errors.Wrapf(err, ".dnshield.yml: rule %d (%s) has an invalid body_template", i, rule.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// RuleForPath returns the first rule whose Path glob matches path
+// (relative to the manifests directory, e.g.
+// "includes/phishing/urlhaus.json"), or nil if none match.
+func (c *Config) RuleForPath(path string) *Rule {
+	for i := range c.Rules {
+		if ok, err := filepath.Match(c.Rules[i].Path, path); err == nil && ok {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
+
+// RenderTitle resolves a PR title: explicit wins if the caller already
+// supplied one; otherwise rule's title_template if it has one, else
+// DefaultTitleTemplate. rule may be nil (no rule matched the path).
+func RenderTitle(rule *Rule, data TemplateData, explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	tmpl := DefaultTitleTemplate
+	if rule != nil && rule.TitleTemplate != "" {
+		tmpl = rule.TitleTemplate
+	}
+	return renderTemplate(tmpl, data)
+}
+
+// RenderBody resolves a PR body the same way RenderTitle resolves a
+// title, against body_template/DefaultBodyTemplate.
+func RenderBody(rule *Rule, data TemplateData, explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	tmpl := DefaultBodyTemplate
+	if rule != nil && rule.BodyTemplate != "" {
+		tmpl = rule.BodyTemplate
+	}
+	return renderTemplate(tmpl, data)
+}
+
+func renderTemplate(tmpl string, data TemplateData) (string, error) {
+	t, err := template.New("policy").Parse(tmpl)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing template")
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "executing template")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"crypto/rand"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// keySize is large enough that the HMAC key itself is never the weak
+// link in the signature.
+const keySize = 32
+
+// loadOrCreateKey reads the HMAC signing key at path, generating and
+// writing a fresh one (mode 0600) if it doesn't exist yet.
+func loadOrCreateKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "reading session key")
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "generating session key")
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, errors.Wrap(err, "writing session key")
+	}
+	return key, nil
+}
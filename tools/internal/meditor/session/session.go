@@ -0,0 +1,244 @@
+// Package session provides signed, persisted HTTP sessions and OAuth CSRF
+// state for the manifest editor. Session records and CSRF state survive a
+// server restart by living in a small BoltDB file rather than an
+// in-process map, and cookie values are HMAC-signed so a client can't
+// forge a session ID it was never issued.
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sessionsBucket = []byte("sessions")
+	csrfBucket     = []byte("csrf")
+)
+
+// Session is a logged-in user's GitHub OAuth session.
+type Session struct {
+	ID          string    `json:"id"`
+	AccessToken string    `json:"access_token"`
+	Login       string    `json:"login"`
+	Name        string    `json:"name"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Store issues, persists, and validates sessions and OAuth CSRF state.
+type Store interface {
+	// New creates and persists a fresh, empty session and returns its ID.
+	New() (string, error)
+	// Save persists changes to an existing session (e.g. after OAuth
+	// exchange fills in the access token).
+	Save(sess *Session) error
+	// Get returns the session for id, or ok=false if it doesn't exist
+	// or has expired.
+	Get(id string) (sess *Session, ok bool, err error)
+	// Delete removes a session, e.g. on logout.
+	Delete(id string) error
+
+	// PutState records that state was issued for sessionID, so the
+	// OAuth callback can recover which session started the flow.
+	PutState(state, sessionID string) error
+	// TakeState looks up and deletes state in one step, so it can only
+	// ever be redeemed once.
+	TakeState(state string) (sessionID string, ok bool, err error)
+
+	// Sign returns a cookie value binding id to an HMAC tag so tampering
+	// is detectable. Verify reverses it, rejecting unsigned or
+	// tampered-with input.
+	Sign(id string) string
+	Verify(cookieValue string) (id string, ok bool)
+
+	// Close releases the underlying storage.
+	Close() error
+}
+
+// stateTTL bounds how long an OAuth CSRF state is redeemable for, so a
+// login that's abandoned partway through doesn't leave state around
+// forever.
+const stateTTL = 10 * time.Minute
+
+type boltStore struct {
+	db  *bolt.DB
+	key []byte
+}
+
+// Open returns a Store backed by a BoltDB file at dbPath, signing cookie
+// values with the HMAC key at keyPath (generated on first use, mode
+// 0600, if it doesn't already exist).
+func Open(dbPath, keyPath string) (Store, error) {
+	key, err := loadOrCreateKey(keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading session signing key")
+	}
+
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "opening session store")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(sessionsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(csrfBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "initializing session store buckets")
+	}
+
+	return &boltStore{db: db, key: key}, nil
+}
+
+func (s *boltStore) New() (string, error) {
+	id, err := randomID(32)
+	if err != nil {
+		return "", err
+	}
+	sess := &Session{ID: id}
+	if err := s.Save(sess); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *boltStore) Save(sess *Session) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return errors.Wrap(err, "marshaling session")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(sess.ID), data)
+	})
+}
+
+func (s *boltStore) Get(id string) (*Session, bool, error) {
+	var sess Session
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &sess)
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "reading session")
+	}
+	if !found {
+		return nil, false, nil
+	}
+	if !sess.ExpiresAt.IsZero() && time.Now().After(sess.ExpiresAt) {
+		_ = s.Delete(id)
+		return nil, false, nil
+	}
+	return &sess, true, nil
+}
+
+func (s *boltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(id))
+	})
+}
+
+type csrfEntry struct {
+	SessionID string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *boltStore) PutState(state, sessionID string) error {
+	entry := csrfEntry{SessionID: sessionID, ExpiresAt: time.Now().Add(stateTTL)}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshaling csrf state")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(csrfBucket).Put([]byte(state), data)
+	})
+}
+
+func (s *boltStore) TakeState(state string) (string, bool, error) {
+	var entry csrfEntry
+	found := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(csrfBucket)
+		data := b.Get([]byte(state))
+		if data == nil {
+			return nil
+		}
+		found = true
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		return b.Delete([]byte(state))
+	})
+	if err != nil {
+		return "", false, errors.Wrap(err, "reading csrf state")
+	}
+	if !found || time.Now().After(entry.ExpiresAt) {
+		return "", false, nil
+	}
+	return entry.SessionID, true, nil
+}
+
+func (s *boltStore) Sign(id string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id))
+	tag := hex.EncodeToString(mac.Sum(nil))
+	return id + "." + tag
+}
+
+func (s *boltStore) Verify(cookieValue string) (string, bool) {
+	id, tag, ok := splitSigned(cookieValue)
+	if !ok {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(tag), []byte(expected)) {
+		return "", false
+	}
+	return id, true
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+// splitSigned splits an "id.tag" cookie value, rejecting anything that
+// isn't in exactly that shape.
+func splitSigned(value string) (id, tag string, ok bool) {
+	for i := len(value) - 1; i >= 0; i-- {
+		if value[i] == '.' {
+			return value[:i], value[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// GenerateToken returns a cryptographically random, URL-safe token of n
+// random bytes, suitable for OAuth CSRF state values.
+func GenerateToken(n int) (string, error) {
+	return randomID(n)
+}
+
+func randomID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generating random session id")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
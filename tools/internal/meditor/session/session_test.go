@@ -0,0 +1,141 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) Store {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "sessions.db"), filepath.Join(dir, "key"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestNewGetSaveDelete(t *testing.T) {
+	store := openTestStore(t)
+
+	id, err := store.New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	sess, ok, err := store.Get(id)
+	if err != nil || !ok {
+		t.Fatalf("Get after New: ok=%v err=%v", ok, err)
+	}
+	if sess.ID != id {
+		t.Fatalf("Get returned session with ID %q, want %q", sess.ID, id)
+	}
+
+	sess.Login = "alice"
+	sess.AccessToken = "token123"
+	if err := store.Save(sess); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, ok, err := store.Get(id)
+	if err != nil || !ok {
+		t.Fatalf("Get after Save: ok=%v err=%v", ok, err)
+	}
+	if got.Login != "alice" || got.AccessToken != "token123" {
+		t.Fatalf("Get after Save = %+v, want Login=alice AccessToken=token123", got)
+	}
+
+	if err := store.Delete(id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, err := store.Get(id); err != nil || ok {
+		t.Fatalf("Get after Delete: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestGetExpiredSessionNotReturned(t *testing.T) {
+	store := openTestStore(t)
+
+	id, err := store.New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sess, _, _ := store.Get(id)
+	sess.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := store.Save(sess); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, ok, err := store.Get(id); err != nil || ok {
+		t.Fatalf("Get on expired session: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestPutStateAndTakeState(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.PutState("state1", "session1"); err != nil {
+		t.Fatalf("PutState: %v", err)
+	}
+
+	sessionID, ok, err := store.TakeState("state1")
+	if err != nil || !ok || sessionID != "session1" {
+		t.Fatalf("TakeState = (%q, %v, %v), want (session1, true, nil)", sessionID, ok, err)
+	}
+
+	// A state can only be redeemed once.
+	if _, ok, err := store.TakeState("state1"); err != nil || ok {
+		t.Fatalf("second TakeState: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestTakeStateUnknown(t *testing.T) {
+	store := openTestStore(t)
+	if _, ok, err := store.TakeState("nonexistent"); err != nil || ok {
+		t.Fatalf("TakeState on unknown state: ok=%v err=%v, want ok=false", ok, err)
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	store := openTestStore(t)
+
+	cookie := store.Sign("session-id")
+	id, ok := store.Verify(cookie)
+	if !ok || id != "session-id" {
+		t.Fatalf("Verify(%q) = (%q, %v), want (session-id, true)", cookie, id, ok)
+	}
+}
+
+func TestVerifyRejectsTamperedOrMalformed(t *testing.T) {
+	store := openTestStore(t)
+	cookie := store.Sign("session-id")
+
+	if _, ok := store.Verify(cookie + "tampered"); ok {
+		t.Fatalf("Verify accepted a tampered cookie value")
+	}
+	if _, ok := store.Verify("no-dot-separator"); ok {
+		t.Fatalf("Verify accepted a value with no signature separator")
+	}
+	if _, ok := store.Verify(""); ok {
+		t.Fatalf("Verify accepted an empty value")
+	}
+}
+
+func TestGenerateTokenLengthAndUniqueness(t *testing.T) {
+	a, err := GenerateToken(16)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	b, err := GenerateToken(16)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if len(a) != 22 {
+		t.Fatalf("GenerateToken(16) length = %d, want 22", len(a))
+	}
+	if a == b {
+		t.Fatalf("GenerateToken produced the same token twice: %q", a)
+	}
+}
@@ -0,0 +1,115 @@
+// Package watch recursively watches the manifest editor's manifests
+// directory for on-disk changes (a git pull, another editor instance
+// writing files) and debounces them into a single cache-invalidation
+// callback, so the server stops serving stale search results and
+// include trees without needing a restart.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// debounceWindow coalesces a burst of filesystem events (e.g. a git
+// checkout touching hundreds of files at once) into a single
+// invalidation pass.
+const debounceWindow = 250 * time.Millisecond
+
+// Watcher recursively watches a directory tree and invalidates a cache
+// after a debounced burst of create/write/remove/rename events.
+type Watcher struct {
+	fsw        *fsnotify.Watcher
+	root       string
+	invalidate func()
+
+	mu         sync.RWMutex
+	lastReload time.Time
+	lastErr    error
+}
+
+// Start begins watching root, and every directory under it recursively,
+// calling invalidate once per debounced burst of changes. The returned
+// Watcher's background goroutine runs until Close is called.
+func Start(root string, invalidate func()) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating filesystem watcher")
+	}
+	if err := addRecursive(fsw, root); err != nil {
+		fsw.Close()
+		return nil, errors.Wrap(err, "watching manifests directory")
+	}
+
+	w := &Watcher{fsw: fsw, root: root, invalidate: invalidate, lastReload: time.Now()}
+	go w.loop()
+	return w, nil
+}
+
+func addRecursive(fsw *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fsw.Add(path)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) loop() {
+	var debounce *time.Timer
+	reload := func() {
+		w.invalidate()
+		w.mu.Lock()
+		w.lastReload = time.Now()
+		w.mu.Unlock()
+	}
+
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			// A newly created directory (e.g. "includes/group" appearing
+			// on a fresh checkout) needs its own watch added so files
+			// written into it afterward are seen too.
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.fsw.Add(event.Name)
+				}
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(debounceWindow, reload)
+			} else {
+				debounce.Reset(debounceWindow)
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			w.lastErr = err
+			w.mu.Unlock()
+		}
+	}
+}
+
+// Status reports the watcher's last successful reload time and the most
+// recent fsnotify error, if any, for exposing over a health endpoint.
+func (w *Watcher) Status() (lastReload time.Time, lastErr error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastReload, w.lastErr
+}
+
+// Close stops the watcher and releases its underlying file descriptors.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
@@ -0,0 +1,102 @@
+//go:build darwin
+// +build darwin
+
+// Package osloghook bridges zerolog's JSON log lines into Apple's unified
+// logging system (os_log), so a background daemon's events show up in
+// Console.app and `log stream`/`log show --predicate` instead of only in
+// whatever stdio sink it was started with.
+package osloghook
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static void dnshield_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+    // os_log_with_type is variadic, which cgo cannot call with a dynamic
+    // format string - %{public}s plus a single pre-formatted argument is
+    // the standard way to bridge an already-built message through it.
+    os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// Writer is an io.Writer that forwards each line it's given (expected to
+// be a single zerolog JSON log entry) to os_log_with_type.
+type Writer struct {
+	log C.os_log_t
+}
+
+// NewWriter creates a Writer reporting under subsystem/category - the
+// same pair `log show --predicate 'subsystem == "..." && category ==
+// "..."'` filters on. subsystem is conventionally the daemon's reverse-DNS
+// bundle ID; category distinguishes components within it.
+func NewWriter(subsystem, category string) *Writer {
+	cSubsystem := C.CString(subsystem)
+	defer C.free(unsafe.Pointer(cSubsystem))
+	cCategory := C.CString(category)
+	defer C.free(unsafe.Pointer(cCategory))
+
+	return &Writer{log: C.os_log_create(cSubsystem, cCategory)}
+}
+
+// knownFields are the structured zerolog fields promoted into the os_log
+// message text itself (rather than left buried in the JSON blob) so
+// they're matchable by `log show --predicate 'eventMessage CONTAINS ...'`.
+var knownFields = []string{"event_type", "db_path", "rule_count"}
+
+// Write parses p as a single zerolog JSON log line and forwards it to
+// os_log_with_type at the level-appropriate OS_LOG_TYPE_*. A line that
+// doesn't parse as JSON is forwarded verbatim at OS_LOG_TYPE_DEFAULT
+// rather than dropped, so logging bugs elsewhere stay visible here too.
+func (w *Writer) Write(p []byte) (int, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		w.log_(C.OS_LOG_TYPE_DEFAULT, strings.TrimSpace(string(p)))
+		return len(p), nil
+	}
+
+	msg, _ := fields["message"].(string)
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, key := range knownFields {
+		if v, ok := fields[key]; ok {
+			fmt.Fprintf(&b, " %s=%v", key, v)
+		}
+	}
+
+	w.log_(osLogType(fields["level"]), b.String())
+	return len(p), nil
+}
+
+func (w *Writer) log_(logType C.os_log_type_t, msg string) {
+	cMsg := C.CString(msg)
+	defer C.free(unsafe.Pointer(cMsg))
+	C.dnshield_os_log(w.log, logType, cMsg)
+}
+
+// osLogType maps a zerolog level string (the "level" field of its JSON
+// output) to the nearest OS_LOG_TYPE_* - os_log has no direct analog of
+// zerolog's warn, so it's reported at OS_LOG_TYPE_DEFAULT alongside
+// everything else zerolog doesn't treat as debug/info/error/fatal.
+func osLogType(level interface{}) C.os_log_type_t {
+	l, _ := level.(string)
+	switch l {
+	case "debug", "trace":
+		return C.OS_LOG_TYPE_DEBUG
+	case "info":
+		return C.OS_LOG_TYPE_INFO
+	case "error":
+		return C.OS_LOG_TYPE_ERROR
+	case "fatal", "panic":
+		return C.OS_LOG_TYPE_FAULT
+	default:
+		return C.OS_LOG_TYPE_DEFAULT
+	}
+}
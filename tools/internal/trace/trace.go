@@ -0,0 +1,81 @@
+// Package trace implements selective debug-level tracing modeled on
+// syncthing's STTRACE: a comma-separated list of facet names (e.g.
+// "hosts,kqueue") turns on fine-grained zerolog.Debug() output for just
+// those subsystems, so a single component can be diagnosed in production
+// without drowning the log in everyone else's debug noise.
+package trace
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Facets are the subsystems that can be traced independently. "all"
+// isn't itself a facet - it's handled specially by Configure to enable
+// every facet at once.
+var Facets = []string{"hosts", "rules", "kqueue", "telemetry", "cfpref"}
+
+var (
+	mu      sync.RWMutex
+	enabled = map[string]bool{}
+	all     bool
+)
+
+// Configure parses spec (as from $DNSHIELD_TRACE or the TraceFacets
+// preference) and replaces the set of enabled facets. Tokens not in
+// Facets (and not "all") are returned as unknown rather than silently
+// ignored, so callers can warn about a typo'd facet name at startup.
+func Configure(spec string) (unknown []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	enabled = map[string]bool{}
+	all = false
+
+	known := make(map[string]bool, len(Facets))
+	for _, f := range Facets {
+		known[f] = true
+	}
+
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.ToLower(strings.TrimSpace(tok))
+		if tok == "" {
+			continue
+		}
+		if tok == "all" {
+			all = true
+			continue
+		}
+		if !known[tok] {
+			unknown = append(unknown, tok)
+			continue
+		}
+		enabled[tok] = true
+	}
+	return unknown
+}
+
+// Enabled reports whether facet-level tracing is turned on for facet.
+func Enabled(facet string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return all || enabled[facet]
+}
+
+// Logger returns a debug-level logger tagged with facet if tracing is
+// enabled for it, or a disabled (zerolog.Nop) logger otherwise, so
+// callers can chain calls unconditionally without checking Enabled
+// first. It returns a pointer since zerolog's event-builder methods
+// require an addressable Logger.
+func Logger(facet string) *zerolog.Logger {
+	var l zerolog.Logger
+	if Enabled(facet) {
+		l = log.Logger.With().Str("trace_facet", facet).Logger()
+	} else {
+		l = zerolog.Nop()
+	}
+	return &l
+}
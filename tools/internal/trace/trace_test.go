@@ -0,0 +1,25 @@
+package trace
+
+import "testing"
+
+func TestConfigureWarnsOnUnknownFacets(t *testing.T) {
+	unknown := Configure("hosts, bogus ,kqueue")
+	if len(unknown) != 1 || unknown[0] != "bogus" {
+		t.Fatalf("expected unknown facet %q to be reported, got %v", "bogus", unknown)
+	}
+	if !Enabled("hosts") || !Enabled("kqueue") {
+		t.Fatalf("expected known facets to be enabled")
+	}
+	if Enabled("rules") {
+		t.Fatalf("expected facet not in spec to stay disabled")
+	}
+}
+
+func TestConfigureAllEnablesEveryFacet(t *testing.T) {
+	Configure("all")
+	for _, f := range Facets {
+		if !Enabled(f) {
+			t.Fatalf("expected facet %q to be enabled under \"all\"", f)
+		}
+	}
+}
@@ -4,8 +4,11 @@
 package user
 
 import (
+	"context"
 	"os/user"
+	"runtime"
 	"strconv"
+	"sync"
 	"unsafe"
 
 	"github.com/pkg/errors"
@@ -42,6 +45,65 @@ void GetConsoleUser(char **username, uid_t *uid, gid_t *gid) {
     CFRelease(user);
     CFRelease(store);
 }
+
+typedef struct {
+    SCDynamicStoreRef store;
+    CFRunLoopSourceRef source;
+} ConsoleUserWatch;
+
+extern void goConsoleUserChanged();
+
+static void consoleUserChangedCallback(SCDynamicStoreRef store, CFArrayRef changedKeys, void *info) {
+    goConsoleUserChanged();
+}
+
+// StartConsoleUserWatch creates an SCDynamicStore subscribed to
+// State:/Users/ConsoleUser and attaches its run loop source to whichever
+// thread's CFRunLoop calls this - the caller must then run that run
+// loop (e.g. with CFRunLoopRun) for consoleUserChangedCallback to ever
+// fire. Returns NULL on any CoreFoundation/SystemConfiguration failure.
+ConsoleUserWatch *StartConsoleUserWatch(void) {
+    SCDynamicStoreContext context = {0, NULL, NULL, NULL, NULL};
+    SCDynamicStoreRef store = SCDynamicStoreCreate(NULL, CFSTR("WatchConsoleUser"), consoleUserChangedCallback, &context);
+    if (store == NULL) {
+        return NULL;
+    }
+
+    CFStringRef key = CFSTR("State:/Users/ConsoleUser");
+    CFArrayRef keys = CFArrayCreate(NULL, (const void **)&key, 1, &kCFTypeArrayCallBacks);
+    if (!SCDynamicStoreSetNotificationKeys(store, keys, NULL)) {
+        CFRelease(keys);
+        CFRelease(store);
+        return NULL;
+    }
+    CFRelease(keys);
+
+    CFRunLoopSourceRef source = SCDynamicStoreCreateRunLoopSource(NULL, store, 0);
+    if (source == NULL) {
+        CFRelease(store);
+        return NULL;
+    }
+    CFRunLoopAddSource(CFRunLoopGetCurrent(), source, kCFRunLoopDefaultMode);
+
+    ConsoleUserWatch *watch = (ConsoleUserWatch *)malloc(sizeof(ConsoleUserWatch));
+    watch->store = store;
+    watch->source = source;
+    return watch;
+}
+
+// StopConsoleUserWatch tears down watch's run loop source and releases
+// its CoreFoundation objects. Must be called on the same thread
+// StartConsoleUserWatch ran on, after that thread's CFRunLoopRun has
+// returned.
+void StopConsoleUserWatch(ConsoleUserWatch *watch) {
+    if (watch == NULL) {
+        return;
+    }
+    CFRunLoopRemoveSource(CFRunLoopGetCurrent(), watch->source, kCFRunLoopDefaultMode);
+    CFRelease(watch->source);
+    CFRelease(watch->store);
+    free(watch);
+}
 */
 import "C"
 
@@ -72,6 +134,94 @@ func GetConsoleUser() (ConsoleUser, error) {
 	}, nil
 }
 
+// watchEvents holds the channel a running WatchConsoleUser emits to, so
+// goConsoleUserChanged (invoked from C on the watch's dedicated run loop
+// thread) has somewhere to deliver the new console user. Only one watch
+// may run at a time - a long-running daemon only ever needs one.
+var (
+	watchMu     sync.Mutex
+	watchEvents chan ConsoleUser
+)
+
+//export goConsoleUserChanged
+func goConsoleUserChanged() {
+	watchMu.Lock()
+	events := watchEvents
+	watchMu.Unlock()
+	if events == nil {
+		return
+	}
+	user, err := GetConsoleUser()
+	if err != nil {
+		return
+	}
+	select {
+	case events <- user:
+	default:
+	}
+}
+
+// WatchConsoleUser emits a new ConsoleUser on the returned channel every
+// time the console user session changes - login, logout, fast user
+// switch, or a loginwindow restart - so a long-running daemon notices
+// instead of acting on the console user GetConsoleUser happened to
+// return when it started, which goes stale the moment per-user trust
+// stores or home-directory paths depend on it. The watch runs on its
+// own OS thread with its own CFRunLoop, and is torn down (run loop
+// stopped, CoreFoundation objects released, channel closed) when ctx is
+// canceled. Only one watch may be active at a time.
+func WatchConsoleUser(ctx context.Context) (<-chan ConsoleUser, error) {
+	watchMu.Lock()
+	if watchEvents != nil {
+		watchMu.Unlock()
+		return nil, errors.New("a console user watch is already running")
+	}
+	events := make(chan ConsoleUser, 1)
+	watchEvents = events
+	watchMu.Unlock()
+
+	ready := make(chan error, 1)
+	go runConsoleUserWatch(ctx, events, ready)
+	if err := <-ready; err != nil {
+		watchMu.Lock()
+		watchEvents = nil
+		watchMu.Unlock()
+		return nil, err
+	}
+	return events, nil
+}
+
+// runConsoleUserWatch owns the watch's dedicated OS thread and
+// CFRunLoop for its entire lifetime: CFRunLoopRemoveSource/CFRelease must run on
+// the same thread that created the run loop source, so this goroutine
+// never unlocks its OS thread until StopConsoleUserWatch has torn
+// everything down.
+func runConsoleUserWatch(ctx context.Context, events chan ConsoleUser, ready chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	watch := C.StartConsoleUserWatch()
+	if watch == nil {
+		ready <- errors.New("failed to start console user watch")
+		return
+	}
+	ready <- nil
+
+	runLoop := C.CFRunLoopGetCurrent()
+	go func() {
+		<-ctx.Done()
+		C.CFRunLoopStop(runLoop)
+	}()
+
+	C.CFRunLoopRun()
+
+	C.StopConsoleUserWatch(watch)
+	watchMu.Lock()
+	watchEvents = nil
+	watchMu.Unlock()
+	close(events)
+}
+
 func (c *ConsoleUser) GroupID() int {
 	return c.gid
 }